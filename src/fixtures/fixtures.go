@@ -0,0 +1,218 @@
+// Package fixtures generates standard test graphs with fixed seeds and finds their
+// reference shortest paths (SP), so routing code of other modules can be tested
+// against known answers.  The vertices are points of the complex plane as in the
+// Dijkstra SP server, and each edge is weighted by its Euclidean length.
+package fixtures
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Seeds and sizes of the standard fixtures
+const (
+	GridSize = 10 // rows and columns of the grid fixture
+
+	RGGSeed     = 1   // seed of the random geometric graph fixture
+	RGGVertices = 100 // vertices of the random geometric graph fixture
+	RGGRadius   = 0.2 // longest edge of the random geometric graph fixture
+
+	ClusteredSeed     = 1    // seed of the clustered fixture
+	ClusteredClusters = 4    // clusters of the clustered fixture
+	ClusteredPer      = 25   // vertices of each cluster of the clustered fixture
+	ClusteredSpread   = 0.06 // standard deviation of the vertices around their cluster center
+	ClusteredRadius   = 0.1  // longest edge of the clustered fixture
+)
+
+// Edge joins the vertices V and W, its weight is the distance between them
+type Edge struct {
+	V, W   int
+	Weight float64
+}
+
+// Graph is an undirected graph of vertices in the plane
+type Graph struct {
+	Name     string
+	Seed     int64 // seed of the random vertices, 0 for the grid
+	Vertices []complex128
+	Edges    []Edge
+}
+
+// Path is the SP from Source to Target, the vertices include both ends
+type Path struct {
+	Source   int
+	Target   int
+	Vertices []int
+	Distance float64
+}
+
+// Fixture is a standard graph and the reference SP between two of its vertices
+type Fixture struct {
+	Graph *Graph
+	SP    Path
+}
+
+// Grid returns the n x n grid with unit spacing.  Vertex i*n+j is at x=j, y=i and is
+// joined to its horizontal and vertical neighbors, so the grid has 2n(n-1) edges and
+// the SP between opposite corners has length 2(n-1).
+func Grid(n int) *Graph {
+	g := &Graph{Name: "grid", Vertices: make([]complex128, 0, n*n)}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			g.Vertices = append(g.Vertices, complex(float64(j), float64(i)))
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			v := i*n + j
+			if j < n-1 {
+				g.Edges = append(g.Edges, Edge{V: v, W: v + 1, Weight: 1})
+			}
+			if i < n-1 {
+				g.Edges = append(g.Edges, Edge{V: v, W: v + n, Weight: 1})
+			}
+		}
+	}
+	return g
+}
+
+// RGG returns the random geometric graph of n vertices drawn uniformly from the unit
+// square by the seed.  Every pair of vertices at most radius apart is an edge.
+func RGG(seed int64, n int, radius float64) *Graph {
+	rng := rand.New(rand.NewSource(seed))
+	g := &Graph{Name: "rgg", Seed: seed, Vertices: make([]complex128, n)}
+	for i := range g.Vertices {
+		g.Vertices[i] = complex(rng.Float64(), rng.Float64())
+	}
+	g.joinWithin(radius)
+	return g
+}
+
+// Clustered returns clusters of per vertices each drawn by the seed.  The cluster
+// centers are uniform in the middle of the unit square and the vertices are normal
+// around them with the spread as standard deviation.  Every pair of vertices at most
+// radius apart is an edge.
+func Clustered(seed int64, clusters, per int, spread, radius float64) *Graph {
+	rng := rand.New(rand.NewSource(seed))
+	g := &Graph{Name: "clustered", Seed: seed, Vertices: make([]complex128, 0, clusters*per)}
+	for c := 0; c < clusters; c++ {
+		center := complex(0.2+0.6*rng.Float64(), 0.2+0.6*rng.Float64())
+		for i := 0; i < per; i++ {
+			g.Vertices = append(g.Vertices, center+complex(spread*rng.NormFloat64(), spread*rng.NormFloat64()))
+		}
+	}
+	g.joinWithin(radius)
+	return g
+}
+
+// joinWithin adds an edge for every pair of vertices at most radius apart
+func (g *Graph) joinWithin(radius float64) {
+	for v := range g.Vertices {
+		for w := v + 1; w < len(g.Vertices); w++ {
+			if d := cmplx.Abs(g.Vertices[v] - g.Vertices[w]); d <= radius {
+				g.Edges = append(g.Edges, Edge{V: v, W: w, Weight: d})
+			}
+		}
+	}
+}
+
+// ShortestPath finds the SP from source to target with Dijkstra's algorithm.  Each
+// step settles the closest vertex, the lowest one of equal distances, so the path is
+// the same on every platform.
+func (g *Graph) ShortestPath(source, target int) (Path, error) {
+	vertices := len(g.Vertices)
+	if source < 0 || target < 0 || source > vertices-1 || target > vertices-1 {
+		return Path{}, fmt.Errorf("source %d and target %d must be 0-%d", source, target, vertices-1)
+	}
+	adj := make([][]Edge, vertices)
+	for _, e := range g.Edges {
+		adj[e.V] = append(adj[e.V], e)
+		adj[e.W] = append(adj[e.W], Edge{V: e.W, W: e.V, Weight: e.Weight})
+	}
+
+	distTo := make([]float64, vertices)
+	edgeTo := make([]int, vertices)
+	settled := make([]bool, vertices)
+	for v := range distTo {
+		distTo[v] = math.Inf(1)
+		edgeTo[v] = -1
+	}
+	distTo[source] = 0
+	for {
+		v := -1
+		for w := range distTo {
+			if !settled[w] && !math.IsInf(distTo[w], 1) && (v < 0 || distTo[w] < distTo[v]) {
+				v = w
+			}
+		}
+		if v < 0 || v == target {
+			break
+		}
+		settled[v] = true
+		for _, e := range adj[v] {
+			if d := distTo[v] + e.Weight; d < distTo[e.W] {
+				distTo[e.W] = d
+				edgeTo[e.W] = v
+			}
+		}
+	}
+	if math.IsInf(distTo[target], 1) {
+		return Path{}, fmt.Errorf("target %d is not reachable from source %d", target, source)
+	}
+
+	path := Path{Source: source, Target: target, Distance: distTo[target]}
+	for v := target; v >= 0; v = edgeTo[v] {
+		path.Vertices = append([]int{v}, path.Vertices...)
+	}
+	return path, nil
+}
+
+// Standard returns the grid, random geometric graph and clustered fixtures with
+// their reference SP between the first and the last vertex
+func Standard() ([]Fixture, error) {
+	graphs := []*Graph{
+		Grid(GridSize),
+		RGG(RGGSeed, RGGVertices, RGGRadius),
+		Clustered(ClusteredSeed, ClusteredClusters, ClusteredPer, ClusteredSpread, ClusteredRadius),
+	}
+	fixtures := make([]Fixture, len(graphs))
+	for i, g := range graphs {
+		sp, err := g.ShortestPath(0, len(g.Vertices)-1)
+		if err != nil {
+			return nil, fmt.Errorf("%s fixture: %v", g.Name, err)
+		}
+		fixtures[i] = Fixture{Graph: g, SP: sp}
+	}
+	return fixtures, nil
+}
+
+// WriteGolden writes the fixture as text: the name, seed and counts, the reference SP,
+// then one line per vertex and per edge.  The numbers have 9 decimals, so a change of
+// the generators or the SP shows in a diff of the golden files.
+func (f Fixture) WriteGolden(w io.Writer) error {
+	g := f.Graph
+	path := make([]string, len(f.SP.Vertices))
+	for i, v := range f.SP.Vertices {
+		path[i] = strconv.Itoa(v)
+	}
+	if _, err := fmt.Fprintf(w, "graph %s seed %d vertices %d edges %d\nsp %d %d distance %.9f path %s\n",
+		g.Name, g.Seed, len(g.Vertices), len(g.Edges), f.SP.Source, f.SP.Target, f.SP.Distance, strings.Join(path, " ")); err != nil {
+		return err
+	}
+	for v, z := range g.Vertices {
+		if _, err := fmt.Fprintf(w, "v %d %.9f %.9f\n", v, real(z), imag(z)); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "e %d %d %.9f\n", e.V, e.W, e.Weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
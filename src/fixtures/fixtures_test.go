@@ -0,0 +1,121 @@
+package fixtures
+
+import (
+	"bytes"
+	"flag"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden fixtures instead of comparing them")
+
+// TestGolden compares the standard fixtures to their golden files in testdata.  After
+// an intended change of a generator, run go test -update to write them again.
+func TestGolden(t *testing.T) {
+	fixtures, err := Standard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fixtures {
+		t.Run(f.Graph.Name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := f.WriteGolden(&b); err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join("testdata", f.Graph.Name+".golden")
+			if *update {
+				if err := os.WriteFile(file, b.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			golden, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b.Bytes(), golden) {
+				t.Fatalf("%s fixture differs from %s", f.Graph.Name, file)
+			}
+		})
+	}
+}
+
+// TestGrid checks the edge count of the grid and the SP between opposite corners
+func TestGrid(t *testing.T) {
+	for _, n := range []int{2, 5, GridSize} {
+		g := Grid(n)
+		if len(g.Vertices) != n*n || len(g.Edges) != 2*n*(n-1) {
+			t.Fatalf("%d x %d grid has %d vertices and %d edges", n, n, len(g.Vertices), len(g.Edges))
+		}
+		sp, err := g.ShortestPath(0, n*n-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sp.Distance != float64(2*(n-1)) || len(sp.Vertices) != 2*n-1 {
+			t.Fatalf("%d x %d grid corner SP %v, want distance %d", n, n, sp, 2*(n-1))
+		}
+	}
+}
+
+// TestRadiusGraphs checks that the seeded graphs repeat, that exactly the pairs of
+// vertices within the radius are edges, and that an SP is no shorter than the
+// straight line between its ends
+func TestRadiusGraphs(t *testing.T) {
+	graphs := []struct {
+		generate func() *Graph
+		radius   float64
+	}{
+		{func() *Graph { return RGG(RGGSeed, RGGVertices, RGGRadius) }, RGGRadius},
+		{func() *Graph {
+			return Clustered(ClusteredSeed, ClusteredClusters, ClusteredPer, ClusteredSpread, ClusteredRadius)
+		}, ClusteredRadius},
+	}
+	for _, gr := range graphs {
+		g, again := gr.generate(), gr.generate()
+		for v := range g.Vertices {
+			if g.Vertices[v] != again.Vertices[v] {
+				t.Fatalf("%s vertex %d is %v and %v with one seed", g.Name, v, g.Vertices[v], again.Vertices[v])
+			}
+		}
+
+		edges := 0
+		for v := range g.Vertices {
+			for w := v + 1; w < len(g.Vertices); w++ {
+				if cmplx.Abs(g.Vertices[v]-g.Vertices[w]) <= gr.radius {
+					edges++
+				}
+			}
+		}
+		for _, e := range g.Edges {
+			if e.Weight > gr.radius || e.Weight != cmplx.Abs(g.Vertices[e.V]-g.Vertices[e.W]) {
+				t.Fatalf("%s edge %d-%d has weight %g", g.Name, e.V, e.W, e.Weight)
+			}
+		}
+		if len(g.Edges) != edges {
+			t.Fatalf("%s has %d edges, %d pairs are within %g", g.Name, len(g.Edges), edges, gr.radius)
+		}
+
+		last := len(g.Vertices) - 1
+		sp, err := g.ShortestPath(0, last)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sp.Distance < cmplx.Abs(g.Vertices[last]-g.Vertices[0]) {
+			t.Fatalf("%s SP distance %g is shorter than the straight line", g.Name, sp.Distance)
+		}
+	}
+}
+
+// TestShortestPathErrors checks that vertices out of range and an unreachable
+// target are errors
+func TestShortestPathErrors(t *testing.T) {
+	g := RGG(RGGSeed, 10, 0)
+	if _, err := g.ShortestPath(0, 10); err == nil {
+		t.Fatal("SP to vertex 10 of 10 vertices has no error")
+	}
+	if _, err := g.ShortestPath(0, 1); err == nil {
+		t.Fatal("SP of a graph without edges has no error")
+	}
+}
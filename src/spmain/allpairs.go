@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	maxAllPairs    = 1000 // most vertices of the all-pairs matrix, Floyd-Warshall takes V^3 steps
+	defaultKNN     = 4    // neighbors of each vertex in the kNN graph
+	sparseMST      = "mst"
+	sparseKNN      = "knn"
+	allPairsNoPath = "inf" // matrix entry of a pair without a path
+)
+
+// sparseGraph returns the weight matrix of the sparsified graph, the MST edges or
+// each vertex joined to its k nearest vertices.  The complete graph already holds
+// the shortest distances, so the transitive closure is only meaningful on a sparse one.
+func (p *PrimMST) sparseGraph(sparse string, k int) ([][]float64, error) {
+	vertices := len(p.location)
+	dist := make([][]float64, vertices)
+	for v := range dist {
+		dist[v] = make([]float64, vertices)
+		for w := range dist[v] {
+			dist[v][w] = infinity
+		}
+		dist[v][v] = 0.0
+	}
+
+	switch sparse {
+	case sparseMST:
+		for _, e := range p.mst.edges() {
+			dist[e.v][e.w] = p.graph[e.v][e.w]
+			dist[e.w][e.v] = p.graph[e.v][e.w]
+		}
+	case sparseKNN:
+		neighbors, err := p.nearestNeighbors(k)
+		if err != nil {
+			return nil, err
+		}
+		for v := range neighbors {
+			for _, w := range neighbors[v] {
+				dist[v][w] = p.graph[v][w]
+			}
+		}
+	default:
+		return nil, fmt.Errorf("sparse %q must be %s or %s", sparse, sparseMST, sparseKNN)
+	}
+
+	return dist, nil
+}
+
+// floydWarshall replaces the weight matrix with the all-pairs shortest distances
+func floydWarshall(dist [][]float64) {
+	for k := range dist {
+		for i := range dist {
+			if dist[i][k] == infinity {
+				continue
+			}
+			for j := range dist {
+				if d := dist[i][k] + dist[k][j]; d < dist[i][j] {
+					dist[i][j] = d
+				}
+			}
+		}
+	}
+}
+
+// HTTP handler for /allpairs connections.  It returns the all-pairs SP distances of the
+// saved graph sparsified to its MST or kNN graph as a CSV matrix, downloaded as an
+// attachment with download=1.  Row v column w is the distance from v to w.
+func handleAllPairs(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+	if vertices := len(primmst.location); vertices > maxAllPairs {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("all pairs needs at most %d vertices, the graph has %d", maxAllPairs, vertices))
+		return
+	}
+	sparse := r.FormValue("sparse")
+	if len(sparse) == 0 {
+		sparse = sparseMST
+	}
+	k, err := formInt(r, "k", defaultKNN)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	dist, err := primmst.sparseGraph(sparse, k)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	floydWarshall(dist)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if r.FormValue("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=allpairs_"+sparse+".csv")
+	}
+	out := csv.NewWriter(w)
+	record := make([]string, len(dist)+1)
+	for v := range dist {
+		record[v+1] = strconv.Itoa(v)
+	}
+	out.Write(record)
+	for v, row := range dist {
+		record[0] = strconv.Itoa(v)
+		for w, d := range row {
+			record[w+1] = allPairsNoPath
+			if d < infinity {
+				record[w+1] = strconv.FormatFloat(d, 'f', 4, 64)
+			}
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		fmt.Printf("Write CSV to HTTP output error: %v\n", err)
+	}
+}
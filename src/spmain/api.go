@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/cmplx"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Stable error codes of the API error responses
+const (
+	apiInvalidInput = "invalid_input" // request parameters are missing or invalid
+	apiNoGraph      = "no_graph"      // the saved graph could not be read
+	apiNoPath       = "no_path"       // no path exists between the vertices
+	apiUnreachable  = "unreachable"   // the SP target cannot be reached from the source
+	apiInternal     = "internal"      // server failure computing the response
+)
+
+// apiStatus maps the API error codes to the HTTP status
+var apiStatus = map[string]int{
+	apiInvalidInput: http.StatusBadRequest,
+	apiNoGraph:      http.StatusNotFound,
+	apiNoPath:       http.StatusNotFound,
+	apiUnreachable:  http.StatusUnprocessableEntity,
+	apiInternal:     http.StatusInternalServerError,
+}
+
+// apiError is an error of the SP search with the API error code of its cause, so an
+// API handler can tell a bad request from a graph without a path
+type apiError struct {
+	code string
+	err  error
+}
+
+func (e *apiError) Error() string { return e.err.Error() }
+
+func (e *apiError) Unwrap() error { return e.err }
+
+// withCode returns err with the API error code, nil if err is nil
+func withCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &apiError{code: code, err: err}
+}
+
+// apiCode returns the API error code of err, internal if it was not given one
+func apiCode(err error) string {
+	var e *apiError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return apiInternal
+}
+
+// Type to contain the API error JSON response {"error": {"code", "message"}}
+type APIErrorT struct {
+	Error struct {
+		Code    string `json:"code"`    // stable error code
+		Message string `json:"message"` // human readable error
+	} `json:"error"`
+}
+
+// Type to contain the grid point to graph coordinates JSON response
+type GridPointT struct {
+	Row     int     `json:"row"`            // grid row from the client click
+	Col     int     `json:"col"`            // grid column from the client click
+	X       float64 `json:"x"`              // x coordinate in the Euclidean graph
+	Y       float64 `json:"y"`              // y coordinate in the Euclidean graph
+	Vertex  int     `json:"vertex"`         // vertex nearest to x,y
+	VertexX float64 `json:"vertexx"`        // x coordinate of the nearest vertex
+	VertexY float64 `json:"vertexy"`        // y coordinate of the nearest vertex
+	Name    string  `json:"name,omitempty"` // name of the nearest vertex, omitted if the graph has none
+}
+
+// Type to contain one MST edge of the /api/mst JSON response
+type MSTEdgeT struct {
+	V        int     `json:"v"`        // vertex already in the tree
+	W        int     `json:"w"`        // vertex connected to the tree by this edge
+	Distance float64 `json:"distance"` // edge distance, custom weight if given
+}
+
+// Type to contain the minimum spanning tree JSON response
+type MSTT struct {
+	Start    int        `json:"start"`    // Prim's algorithm starting vertex
+	Distance float64    `json:"distance"` // total distance of the MST edges
+	Edges    []MSTEdgeT `json:"edges"`    // MST edges
+}
+
+// Type to contain one neighbor of the /api/nearest JSON response
+type NeighborT struct {
+	Vertex   int     `json:"vertex"`         // neighbor vertex
+	X        float64 `json:"x"`              // x coordinate of the neighbor
+	Y        float64 `json:"y"`              // y coordinate of the neighbor
+	Distance float64 `json:"distance"`       // Euclidean distance from the query vertex
+	Name     string  `json:"name,omitempty"` // neighbor name, omitted if the graph has none
+}
+
+// Type to contain the k nearest neighbors JSON response
+type NearestT struct {
+	Vertex    int         `json:"vertex"`    // query vertex
+	Neighbors []NeighborT `json:"neighbors"` // nearest vertices sorted by distance
+}
+
+// formInt gets the integer form value, or the default if it is empty
+func formInt(r *http.Request, name string, def int) (int, error) {
+	str := r.FormValue(name)
+	if len(str) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not an integer", name, str)
+	}
+	return n, nil
+}
+
+// writeJSON writes the value to HTTP as JSON
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("Write JSON to HTTP output error: %v\n", err)
+	}
+}
+
+// writeAPIError writes the API error to HTTP as JSON with the HTTP status of the code
+func writeAPIError(w http.ResponseWriter, code string, message string) {
+	status, ok := apiStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	var apiErr APIErrorT
+	apiErr.Error.Code = code
+	apiErr.Error.Message = message
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(apiErr); err != nil {
+		fmt.Printf("Write JSON to HTTP output error: %v\n", err)
+	}
+}
+
+// HTTP handler for /api/gridpoint connections.  It maps the grid row and column
+// of a client click back to the graph coordinates and the nearest vertex.  The grid
+// has gridsize rows and columns, 300 if empty.
+func handleGridPoint(w http.ResponseWriter, r *http.Request) {
+	row, err := formInt(r, "row", -1)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	col, err := formInt(r, "col", -1)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	size, err := formInt(r, "gridsize", defaultGridSize)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if size < minGridSize || size > maxGridSize {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("gridsize must be %d-%d", minGridSize, maxGridSize))
+		return
+	}
+	if row < 0 || row > size-1 || col < 0 || col > size-1 {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("row and col must be 0-%d", size-1))
+		return
+	}
+	graph, code, err := apiGraph(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	// The grid orientation of the client click
+	view := *graph.Endpoints
+	switch orientation := r.FormValue("orientation"); orientation {
+	case "", orientationMath:
+	case orientationScreen:
+		view.screenY = true
+	default:
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("orientation %q must be %s or %s", orientation, orientationMath, orientationScreen))
+		return
+	}
+
+	gp := GridPointT{Row: row, Col: col}
+	gp.X, gp.Y = view.fromGrid(row, col, size)
+	gp.Vertex = nearestVertex(graph.location, complex(gp.X, gp.Y))
+	if gp.Vertex >= 0 {
+		gp.VertexX = real(graph.location[gp.Vertex])
+		gp.VertexY = imag(graph.location[gp.Vertex])
+		if len(graph.names) > 0 {
+			gp.Name = graph.names[gp.Vertex]
+		}
+	}
+
+	writeJSON(w, gp)
+}
+
+// apiGraph reads the saved graph selected by the graphblock and slot values.  On
+// failure it returns the API error code.
+func apiGraph(r *http.Request) (*GraphBlock, string, error) {
+	block, err := formInt(r, "graphblock", 0)
+	if err != nil {
+		return nil, apiInvalidInput, err
+	}
+
+	file, err := slotFile(r.FormValue("slot"))
+	if err != nil {
+		return nil, apiInvalidInput, err
+	}
+
+	graph, _, err := readGraphFile(file, block)
+	if err != nil {
+		return nil, apiNoGraph, err
+	}
+
+	return graph, "", nil
+}
+
+// apiMST reads the saved graph selected by the graphblock and slot values and finds
+// its MST using the custom edge weights if given.  On failure it returns the API error code.
+func apiMST(r *http.Request) (*PrimMST, string, error) {
+	graph, code, err := apiGraph(r)
+	if err != nil {
+		return nil, code, err
+	}
+
+	metric, err := parseMetric(r.FormValue("metric"))
+	if err != nil {
+		return nil, apiInvalidInput, err
+	}
+
+	primmst := &PrimMST{location: graph.location, attr: graph.attr, names: graph.names, Endpoints: graph.Endpoints,
+		graphID: graph.id, metric: metric, plot: &PlotT{}}
+	key, cacheable := primmst.mstKey(r)
+	if cacheable && primmst.cachedMST(key) {
+		return primmst, "", nil
+	}
+	if err := primmst.parseObstacles(r); err != nil {
+		return nil, apiInvalidInput, err
+	}
+	if err := primmst.findDistances(); err != nil {
+		return nil, apiInternal, err
+	}
+	if err := primmst.applyEdgeProb(r); err != nil {
+		return nil, apiInvalidInput, err
+	}
+	// Custom edge weights replace the Euclidean distances
+	if err := primmst.applyWeights(r); err != nil {
+		return nil, apiInvalidInput, err
+	}
+	if err := primmst.applyKNN(r); err != nil {
+		return nil, apiInvalidInput, err
+	}
+	if err := primmst.parseStart(r); err != nil {
+		return nil, apiInvalidInput, err
+	}
+	if err := primmst.findMST(); err != nil {
+		return nil, apiCode(err), err
+	}
+	if cacheable {
+		primmst.cacheMST(key)
+	}
+
+	return primmst, "", nil
+}
+
+// newDijkstraSP creates the Dijkstra SP instance sharing the graph and MST of the Prim MST
+func newDijkstraSP(p *PrimMST) *DijksraSP {
+	return &DijksraSP{
+		mst:       p.mst,
+		start:     p.start,
+		neighbors: p.neighbors,
+		graph:     p.graph,
+		location:  p.location,
+		plot:      p.plot,
+		metric:    p.metric,
+		attr:      p.attr,
+		names:     p.names,
+		Endpoints: p.Endpoints,
+	}
+}
+
+// HTTP handler for /api/mst connections.  It computes the MST of the saved graph
+// and returns the edges and total distance without rendering the grid.
+func handleMST(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	mst := MSTT{Start: primmst.start, Edges: make([]MSTEdgeT, 0, len(primmst.mst))}
+	for _, e := range primmst.mst {
+		// The starting vertex has no edge into the tree
+		if e == nil {
+			continue
+		}
+		distance := primmst.graph[e.v][e.w]
+		mst.Edges = append(mst.Edges, MSTEdgeT{V: e.v, W: e.w, Distance: distance})
+		mst.Distance += distance
+	}
+
+	writeJSON(w, mst)
+}
+
+// nearestVertices returns the k vertices closest to vertex v sorted by distance
+func nearestVertices(location []complex128, v, k int) []NeighborT {
+	neighbors := make([]NeighborT, 0, len(location)-1)
+	for w, z := range location {
+		if w == v {
+			continue
+		}
+		neighbors = append(neighbors, NeighborT{Vertex: w, X: real(z), Y: imag(z), Distance: cmplx.Abs(z - location[v])})
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		return neighbors[i].Distance < neighbors[j].Distance
+	})
+	if k < len(neighbors) {
+		neighbors = neighbors[:k]
+	}
+	return neighbors
+}
+
+// HTTP handler for /api/nearest connections.  It returns the k vertices of the saved
+// graph nearest to the vertex.
+func handleNearest(w http.ResponseWriter, r *http.Request) {
+	graph, code, err := apiGraph(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	vertices := len(graph.location)
+	v, err := formInt(r, "vertex", -1)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if v < 0 || v > vertices-1 {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("vertex must be 0-%d", vertices-1))
+		return
+	}
+	k, err := formInt(r, "k", 5)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if k < 1 {
+		writeAPIError(w, apiInvalidInput, "k must be 1 or more")
+		return
+	}
+
+	neighbors := nearestVertices(graph.location, v, k)
+	if len(graph.names) > 0 {
+		for i := range neighbors {
+			neighbors[i].Name = graph.names[neighbors[i].Vertex]
+		}
+	}
+	writeJSON(w, NearestT{Vertex: v, Neighbors: neighbors})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+)
+
+// Type to contain the /api/sp JSON request
+type SPRequestT struct {
+	Vertices int     `json:"vertices"`       // number of random vertices
+	Xmin     float64 `json:"xmin"`           // x start of the Euclidean graph
+	Ymin     float64 `json:"ymin"`           // y start of the Euclidean graph
+	Xmax     float64 `json:"xmax"`           // x end of the Euclidean graph
+	Ymax     float64 `json:"ymax"`           // y end of the Euclidean graph
+	Source   int     `json:"source"`         // SP source vertex
+	Target   int     `json:"target"`         // SP target vertex
+	Seed     *int64  `json:"seed,omitempty"` // seed of the vertex layout, random if omitted
+}
+
+// Type to contain the /api/sp JSON response
+type SPT struct {
+	Seed        int64        `json:"seed"`        // seed of the vertex layout, to repeat the request
+	Path        []int        `json:"path"`        // SP vertices from source to target
+	Coordinates [][2]float64 `json:"coordinates"` // x,y of the path vertices
+	Distance    float64      `json:"distance"`    // SP distance
+}
+
+// randomGraph places verts random vertices of the seed in the endpoints and finds
+// their Euclidean distances and MST
+func randomGraph(verts int, ep *Endpoints, seed int64) (*PrimMST, error) {
+	primmst := &PrimMST{Endpoints: ep, plot: &PlotT{}}
+	primmst.randomVertices(verts, rand.New(rand.NewSource(seed)))
+	if err := primmst.findDistances(); err != nil {
+		return nil, err
+	}
+	// The complete graph is connected, an unreachable target is reported by the SP
+	if err := primmst.findMST(); err != nil {
+		fmt.Printf("findMST error: %v\n", err)
+	}
+	return primmst, nil
+}
+
+// computeSP generates the random vertices of the request and finds the SP between
+// source and target.  It needs no HTTP request, so the -cli mode uses it too.  On
+// failure it returns the API error code.
+func computeSP(req SPRequestT) (SPT, string, error) {
+	if err := checkVertexCount(req.Vertices); err != nil {
+		return SPT{}, apiInvalidInput, err
+	}
+	ep, err := newEndpoints(req.Xmin, req.Ymin, req.Xmax, req.Ymax)
+	if err != nil {
+		return SPT{}, apiInvalidInput, err
+	}
+	seed := rand.Int63()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	primmst, err := randomGraph(req.Vertices, ep, seed)
+	if err != nil {
+		return SPT{}, apiInternal, err
+	}
+
+	dsp := newDijkstraSP(primmst)
+	dsp.source, dsp.target = req.Source, req.Target
+	if err := dsp.checkSourceTarget(); err != nil {
+		return SPT{}, apiInvalidInput, err
+	}
+	dsp.searchSP()
+	path := dsp.pathVertices()
+	if dsp.distTo[dsp.target] == infinity || path == nil {
+		return SPT{}, apiUnreachable, fmt.Errorf("target vertex %d unreachable from source vertex %d", dsp.target, dsp.source)
+	}
+
+	sp := SPT{Seed: seed, Path: path, Coordinates: make([][2]float64, len(path)), Distance: dsp.distTo[dsp.target]}
+	for i, v := range path {
+		sp.Coordinates[i] = [2]float64{real(dsp.location[v]), imag(dsp.location[v])}
+	}
+	return sp, "", nil
+}
+
+// HTTP handler for /api/sp connections.  It generates the random vertices of the JSON
+// request and returns the SP between source and target without rendering the grid.
+// The saved graph is not changed.
+func handleSP(w http.ResponseWriter, r *http.Request) {
+	var req SPRequestT
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("JSON request error: %v", err))
+		return
+	}
+	sp, code, err := computeSP(req)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+	writeJSON(w, sp)
+}
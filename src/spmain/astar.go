@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// findSPAStar constructs the shortest path from the source to the target of the HTML
+// form with A*, the search of findSP ordered by the distance from the source plus the
+// straight-line distance to the target.  The settled vertices and relaxed edges are
+// reported beside those of Dijkstra's algorithm.
+func (dsp *DijksraSP) findSPAStar(r *http.Request) error {
+	dsp.astar = true
+	if err := dsp.findSP(r); err != nil {
+		return err
+	}
+	baseline := *dsp
+	baseline.astar = false
+	baseline.searchAdjacency()
+	dsp.plot.Settled = fmt.Sprintf("%d, Dijkstra %d", dsp.settled, baseline.settled)
+	dsp.plot.Relaxed = fmt.Sprintf("%d, Dijkstra %d", dsp.relaxed, baseline.relaxed)
+	return nil
+}
+
+// heuristic returns the A* estimate of the distance from each vertex to the target,
+// the straight-line distance in the graph metric.  It never exceeds the SP distance
+// since every edge weighs its straight-line distance.  Custom edge weights can weigh
+// less, so with them and for Dijkstra's algorithm the estimate is 0.
+func (dsp *DijksraSP) heuristic() func(v int) float64 {
+	if !dsp.astar || len(dsp.plot.EdgeWeights) > 0 {
+		return func(v int) float64 { return 0 }
+	}
+	target := dsp.location[dsp.target]
+	return func(v int) float64 {
+		return dsp.distance(dsp.metric, dsp.location[v], target)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const (
+	searchSeeds    = 5  // random graphs compared by TestSearches
+	searchVertices = 80 // vertices of each compared graph
+	searchPairs    = 20 // source/target pairs compared on each graph
+)
+
+// TestSearches compares the SP distances of A* and the bidirectional search to those
+// of Dijkstra's algorithm on seeded random graphs, on the MST and on the full graph.
+// It also checks the edges relaxed by Dijkstra's algorithm against its settled vertices.
+// It fails at the first pair whose distances or counts differ.
+func TestSearches(t *testing.T) {
+	for s := int64(1); s <= searchSeeds; s++ {
+		rng := rand.New(rand.NewSource(s))
+		bounds := Endpoints{xmin: 0, ymin: 0, xmax: 100, ymax: 100}
+		location := make([]complex128, searchVertices)
+		for i := range location {
+			location[i] = complex(100*rng.Float64(), 100*rng.Float64())
+		}
+		primmst := &PrimMST{plot: &PlotT{}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		for pair := 0; pair < searchPairs; pair++ {
+			dsp := newDijkstraSP(primmst)
+			dsp.source, dsp.target = rng.Intn(searchVertices), rng.Intn(searchVertices)
+			dsp.fullGraph = pair%2 == 1
+			dsp.searchSP()
+			want := dsp.distTo[dsp.target]
+			// Each settled vertex but the target relaxes all its edges, a second search counts
+			// the same work again
+			relaxed := 0
+			for v, done := range dsp.settledTo {
+				if done && v != dsp.target {
+					relaxed += len(dsp.adj[v])
+				}
+			}
+			settled := dsp.settled
+			if dsp.searchSP(); dsp.relaxed != relaxed || dsp.settled != settled {
+				t.Fatalf("seed %d source %d target %d: Dijkstra relaxed %d edges and settled %d vertices, want %d and %d",
+					s, dsp.source, dsp.target, dsp.relaxed, dsp.settled, relaxed, settled)
+			}
+			dsp.astar = true
+			dsp.searchSP()
+			if got := dsp.distTo[dsp.target]; lessDistance(got, want) || lessDistance(want, got) {
+				t.Fatalf("seed %d source %d target %d full graph %v: A* distance %g, Dijkstra %g",
+					s, dsp.source, dsp.target, dsp.fullGraph, got, want)
+			}
+			dsp.astar = false
+			dsp.searchSPBidirectional()
+			if got := dsp.distTo[dsp.target]; lessDistance(got, want) || lessDistance(want, got) {
+				t.Fatalf("seed %d source %d target %d full graph %v: bidirectional distance %g, Dijkstra %g",
+					s, dsp.source, dsp.target, dsp.fullGraph, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// negativeEdges returns the negative edges of the custom edge weights, each directed
+// from v to w of its "v,w,weight" line
+func (dsp *DijksraSP) negativeEdges() (map[Edge]bool, error) {
+	directed := make(map[Edge]bool)
+	for _, line := range strings.Split(dsp.plot.EdgeWeights, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		v, w, weight, err := parseEdgeWeight(line, len(dsp.location))
+		if err != nil {
+			return nil, err
+		}
+		if weight < 0 {
+			directed[Edge{v: v, w: w}] = true
+		}
+	}
+	return directed, nil
+}
+
+// findSPBellmanFord constructs the shortest path from the source to the target of the
+// HTML form with the Bellman-Ford algorithm, which allows negative edge weights.  An
+// undirected negative edge could be walked back and forth and would itself be a
+// negative cycle, so the search walks a negative edge only from v to w of its
+// custom edge weight line.  A negative cycle is returned as an error since no
+// shortest path exists.
+func (dsp *DijksraSP) findSPBellmanFord(r *http.Request) error {
+	if err := dsp.parseSourceTarget(r); err != nil {
+		return err
+	}
+	if r.PostFormValue("fullgraph") == "on" {
+		dsp.plot.FullGraph = "checked"
+		dsp.fullGraph = true
+	}
+	dsp.buildAdjacency()
+	if err := dsp.checkComponents(); err != nil {
+		return err
+	}
+	directed, err := dsp.negativeEdges()
+	if err != nil {
+		return err
+	}
+
+	vertices := len(dsp.location)
+	dsp.edgeTo = make([]*Edge, vertices)
+	dsp.distTo = make([]float64, vertices)
+	for i := range dsp.distTo {
+		dsp.distTo[i] = infinity
+	}
+	dsp.distTo[dsp.source] = 0.0
+
+	// relax lowers the distances through every edge in both directions, a negative edge
+	// in its own direction, and returns the last edge that lowered a distance, nil if
+	// none did
+	relax := func() *Edge {
+		var lowered *Edge
+		for v := range dsp.adj {
+			if dsp.distTo[v] == infinity {
+				continue
+			}
+			for _, e := range dsp.adj[v] {
+				w := e.w
+				if w == v {
+					w = e.v
+				}
+				if dsp.graph[v][w] < 0 && !directed[Edge{v: v, w: w}] {
+					continue
+				}
+				dsp.relaxed++
+				if newDistance := dsp.distTo[v] + dsp.graph[v][w]; lessDistance(newDistance, dsp.distTo[w]) {
+					dsp.distTo[w] = newDistance
+					dsp.edgeTo[w] = &Edge{v: v, w: w}
+					lowered = dsp.edgeTo[w]
+				}
+			}
+		}
+		return lowered
+	}
+
+	// Bellman-Ford settles no vertex, it relaxes every reached edge in every pass
+	dsp.settled, dsp.relaxed = 0, 0
+
+	// V-1 passes find every shortest path, stop early when a pass lowers nothing
+	for pass := 1; pass < vertices; pass++ {
+		if relax() == nil {
+			return nil
+		}
+	}
+	// A distance lowered in the Vth pass is on or behind a negative cycle
+	if e := relax(); e != nil {
+		dsp.distTo[dsp.target] = infinity
+		return fmt.Errorf("negative cycle reachable from source %d through edge %d-%d, no shortest path exists", dsp.source, e.v, e.w)
+	}
+
+	return nil
+}
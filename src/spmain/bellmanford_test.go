@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestBellmanFordNegative searches a triangle with a negative custom edge from vertex
+// 1 to vertex 2.  The edge lowers the SP in its own direction, is not walked back, and
+// is no negative cycle by itself.  Edges around it short enough to close a negative
+// cycle are reported.
+func TestBellmanFordNegative(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}
+	newSP := func(weights [3]float64, edgeWeights string) *DijksraSP {
+		graph := [][]float64{
+			{infinity, weights[0], weights[2]},
+			{weights[0], infinity, weights[1]},
+			{weights[2], weights[1], infinity},
+		}
+		return &DijksraSP{plot: &PlotT{EdgeWeights: edgeWeights}, location: location, graph: graph,
+			mst: make(MST, len(location)), Endpoints: &bounds, metric: metricEuclidean}
+	}
+
+	searches := []struct {
+		source, target string
+		distance       float64
+	}{
+		{"0", "2", 1}, // 0-1 and the negative edge 1-2 beat the edge 0-2
+		{"2", "1", 5}, // the negative edge is not walked from 2 to 1
+	}
+	for _, s := range searches {
+		dsp := newSP([3]float64{2, -1, 3}, "1,2,-1")
+		err := dsp.findSPBellmanFord(postForm(url.Values{"sourcevert": {s.source}, "targetvert": {s.target}, "fullgraph": {"on"}}))
+		if err != nil {
+			t.Fatalf("SP from %s to %s: %v", s.source, s.target, err)
+		}
+		if d := dsp.distTo[dsp.target]; d != s.distance {
+			t.Fatalf("SP from %s to %s has distance %g, want %g", s.source, s.target, d, s.distance)
+		}
+	}
+
+	// 1 to 2 to 0 and back to 1 is a cycle of -3
+	dsp := newSP([3]float64{1, -5, 1}, "1,2,-5")
+	err := dsp.findSPBellmanFord(postForm(url.Values{"sourcevert": {"0"}, "targetvert": {"2"}, "fullgraph": {"on"}}))
+	if err == nil || !strings.Contains(err.Error(), "negative cycle") {
+		t.Fatalf("SP through a negative cycle gave error %v", err)
+	}
+}
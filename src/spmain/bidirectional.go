@@ -0,0 +1,142 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"net/http"
+)
+
+// findSPBidirectional constructs the shortest path from the source to the target of
+// the HTML form with two Dijkstra searches, forward from the source and backward from
+// the target, which stop when no path through the frontiers can be shorter than the
+// best path through a vertex reached by both.  The settled vertices and relaxed edges
+// of both searches are reported beside those of the forward search alone.
+func (dsp *DijksraSP) findSPBidirectional(r *http.Request) error {
+	if err := dsp.parseSourceTarget(r); err != nil {
+		return err
+	}
+	if r.PostFormValue("fullgraph") == "on" {
+		dsp.plot.FullGraph = "checked"
+		dsp.fullGraph = true
+	}
+	dsp.buildAdjacency()
+	if err := dsp.checkComponents(); err != nil {
+		return err
+	}
+	dsp.searchAdjacencyBidirectional()
+
+	// Dijkstra's algorithm searches the same adjacency list
+	baseline := *dsp
+	baseline.searchAdjacency()
+	dsp.plot.Settled = fmt.Sprintf("%d, Dijkstra %d", dsp.settled, baseline.settled)
+	dsp.plot.Relaxed = fmt.Sprintf("%d, Dijkstra %d", dsp.relaxed, baseline.relaxed)
+
+	return dsp.checkExclusion()
+}
+
+// searchSPBidirectional builds the adjacency list and searches it from both ends
+func (dsp *DijksraSP) searchSPBidirectional() {
+	dsp.buildAdjacency()
+	dsp.searchAdjacencyBidirectional()
+}
+
+// searchAdjacencyBidirectional alternates the forward and backward searches of the
+// built adjacency list, settling the vertex of the frontier with the smaller distance.
+// The edges are undirected, so the backward search uses the same adjacency list.
+// edgeTo and distTo are filled in along the path from source to target through the
+// meeting vertex.
+func (dsp *DijksraSP) searchAdjacencyBidirectional() {
+	vertices := len(dsp.location)
+
+	type frontier struct {
+		distTo  []float64
+		prev    []int
+		settled []bool
+		pq      PriorityQueue
+	}
+	newFrontier := func(start int) *frontier {
+		f := &frontier{distTo: make([]float64, vertices), prev: make([]int, vertices),
+			settled: make([]bool, vertices), pq: newPriorityQueue()}
+		for i := range f.distTo {
+			f.distTo[i] = infinity
+			f.prev[i] = -1
+		}
+		f.distTo[start] = 0.0
+		heap.Push(&f.pq, &Item{Edge: Edge{v: start, w: start}, distance: 0.0})
+		return f
+	}
+	forward, backward := newFrontier(dsp.source), newFrontier(dsp.target)
+
+	// best is the shortest source to target distance through meet found so far
+	best, meet := infinity, -1
+	dsp.settled, dsp.relaxed = 0, 0
+	for forward.pq.Len() > 0 && backward.pq.Len() > 0 {
+		// No unsettled path is shorter than the sum of the frontier distances
+		if !lessDistance(forward.pq.peek()+backward.pq.peek(), best) {
+			break
+		}
+		f, other := forward, backward
+		if backward.pq.peek() < forward.pq.peek() {
+			f, other = backward, forward
+		}
+		item := heap.Pop(&f.pq).(*Item)
+		v := item.w
+		// Items are inserted again instead of updated, stale items are skipped
+		if f.settled[v] {
+			continue
+		}
+		f.settled[v] = true
+		dsp.settled++
+		for _, e := range dsp.adj[v] {
+			dsp.relaxed++
+			w := e.w
+			if w == v {
+				w = e.v
+			}
+			newDistance := f.distTo[v] + dsp.graph[v][w]
+			if lessDistance(newDistance, f.distTo[w]) {
+				f.distTo[w] = newDistance
+				f.prev[w] = v
+				heap.Push(&f.pq, &Item{Edge: Edge{v: v, w: w}, distance: newDistance})
+			}
+			if through := f.distTo[w] + other.distTo[w]; lessDistance(through, best) {
+				best, meet = through, w
+			}
+		}
+		if through := f.distTo[v] + other.distTo[v]; lessDistance(through, best) {
+			best, meet = through, v
+		}
+	}
+
+	dsp.edgeTo = make([]*Edge, vertices)
+	dsp.distTo = make([]float64, vertices)
+	for i := range dsp.distTo {
+		dsp.distTo[i] = infinity
+	}
+	if meet < 0 {
+		return
+	}
+
+	// The forward search reaches meet from the source, the backward one leads on to the target
+	path := make([]int, 0)
+	for v := meet; v != -1; v = forward.prev[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for v := backward.prev[meet]; v != -1; v = backward.prev[v] {
+		path = append(path, v)
+	}
+	dsp.distTo[dsp.source] = 0.0
+	for i := 1; i < len(path); i++ {
+		v, w := path[i-1], path[i]
+		dsp.edgeTo[w] = &Edge{v: v, w: w}
+		dsp.distTo[w] = dsp.distTo[v] + dsp.graph[v][w]
+	}
+}
+
+// peek returns the smallest distance in the priority queue without removing it
+func (pq PriorityQueue) peek() float64 {
+	return pq.items[0].distance
+}
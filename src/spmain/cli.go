@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// runCLI finds the SP of the request as /api/sp does and prints the seed, the path
+// vertices and the distance to w, one per line, for the -cli mode without the server
+func runCLI(w io.Writer, req SPRequestT) error {
+	sp, _, err := computeSP(req)
+	if err != nil {
+		return err
+	}
+	path := make([]string, len(sp.Path))
+	for i, v := range sp.Path {
+		path[i] = strconv.Itoa(v)
+	}
+	_, err = fmt.Fprintf(w, "seed %d\npath %s\ndistance %s\n", sp.Seed, strings.Join(path, " "),
+		strconv.FormatFloat(sp.Distance, 'g', -1, 64))
+	return err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCLI prints the SP of a seeded random graph twice as the -cli mode does and
+// checks that the output is the same, that it is the path and distance computeSP
+// finds and that a source out of range is an invalid input
+func TestCLI(t *testing.T) {
+	seed := int64(deterministicSeed)
+	req := SPRequestT{Vertices: 50, Xmax: 100, Ymax: 100, Source: 3, Target: 41, Seed: &seed}
+	var first, second bytes.Buffer
+	if err := runCLI(&first, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI(&second, req); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Fatalf("seed %d printed %q and then %q", seed, first.String(), second.String())
+	}
+
+	sp, _, err := computeSP(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.Path[0] != req.Source || sp.Path[len(sp.Path)-1] != req.Target {
+		t.Fatalf("path %v does not run from %d to %d", sp.Path, req.Source, req.Target)
+	}
+	path := make([]string, len(sp.Path))
+	for i, v := range sp.Path {
+		path[i] = strconv.Itoa(v)
+	}
+	want := fmt.Sprintf("seed %d\npath %s\ndistance %s\n", seed, strings.Join(path, " "), strconv.FormatFloat(sp.Distance, 'g', -1, 64))
+	if first.String() != want {
+		t.Fatalf("printed %q, want %q", first.String(), want)
+	}
+
+	req.Source = req.Vertices
+	if _, code, err := computeSP(req); err == nil || code != apiInvalidInput {
+		t.Fatalf("source %d out of range gave code %q error %v", req.Source, code, err)
+	}
+}
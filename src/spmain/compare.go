@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math/cmplx"
+	"net/http"
+	"strconv"
+)
+
+const fileCompare = "templates/compare.html" // html for the graph comparison
+
+// Type to contain the MST summary of one saved graph in the comparison
+type GraphSummaryT struct {
+	Slot     string // graph slot, default for the empty slot
+	Vertices string // number of vertices
+	Edges    string // number of MST edges
+	Distance string // MST total distance
+	MeanEdge string // mean MST edge length
+	Diameter string // longest MST path between two vertices
+	Spread   string // mean distance of the vertices from their centroid
+	Bounds   string // Euclidean graph endpoints
+	spread   float64
+}
+
+// Type to contain the HTML template actions of the graph comparison
+type CompareT struct {
+	SlotA  string          // first graph slot of the form
+	SlotB  string          // second graph slot of the form
+	Graphs []GraphSummaryT // summaries of the two graphs
+	Note   string          // which graph is more spread out, or the error
+}
+
+// summarizeSlot reads graph block 0 of the slot, finds its MST with the Euclidean
+// distances and summarizes it
+func summarizeSlot(slot string) (*GraphSummaryT, error) {
+	file, err := slotFile(slot)
+	if err != nil {
+		return nil, err
+	}
+	graph, _, err := readGraphFile(file, 0)
+	if err != nil {
+		return nil, err
+	}
+	primmst := &PrimMST{location: graph.location, Endpoints: graph.Endpoints, metric: metricEuclidean, plot: &PlotT{}}
+	if err := primmst.findDistances(); err != nil {
+		return nil, err
+	}
+	if err := primmst.findMST(); err != nil {
+		return nil, err
+	}
+
+	vertices := len(primmst.location)
+	distance := 0.0
+	for _, e := range primmst.mst.edges() {
+		distance += primmst.graph[e.v][e.w]
+	}
+
+	// The diameter ends at the vertex farthest from any vertex, the MST is a tree
+	dsp := newDijkstraSP(primmst)
+	dsp.buildAdjacency()
+	farthest := func(src int) (int, float64) {
+		distTo, _ := dsp.shortestFrom(src)
+		far := src
+		for v, d := range distTo {
+			if d > distTo[far] {
+				far = v
+			}
+		}
+		return far, distTo[far]
+	}
+	end, _ := farthest(primmst.start)
+	_, diameter := farthest(end)
+
+	// Spread is the mean distance from the centroid
+	var centroid complex128
+	for _, z := range primmst.location {
+		centroid += z
+	}
+	centroid /= complex(float64(vertices), 0)
+	spread := 0.0
+	for _, z := range primmst.location {
+		spread += cmplx.Abs(z - centroid)
+	}
+	spread /= float64(vertices)
+
+	summary := &GraphSummaryT{
+		Slot:     slot,
+		Vertices: strconv.Itoa(vertices),
+		Edges:    strconv.Itoa(vertices - 1),
+		Distance: fmt.Sprintf("%.2f", distance),
+		Diameter: fmt.Sprintf("%.2f", diameter),
+		Spread:   fmt.Sprintf("%.2f", spread),
+		Bounds:   fmt.Sprintf("(%.2f, %.2f) - (%.2f, %.2f)", primmst.xmin, primmst.ymin, primmst.xmax, primmst.ymax),
+		spread:   spread,
+	}
+	if len(slot) == 0 {
+		summary.Slot = "default"
+	}
+	if vertices > 1 {
+		summary.MeanEdge = fmt.Sprintf("%.2f", distance/float64(vertices-1))
+	}
+	return summary, nil
+}
+
+// HTTP handler for /compare connections.  It summarizes the MSTs of two saved graph
+// slots side by side.
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	compare := &CompareT{SlotA: r.FormValue("slota"), SlotB: r.FormValue("slotb")}
+	if r.FormValue("compare") == "on" {
+		a, err := summarizeSlot(compare.SlotA)
+		if err == nil {
+			var b *GraphSummaryT
+			if b, err = summarizeSlot(compare.SlotB); err == nil {
+				compare.Graphs = []GraphSummaryT{*a, *b}
+				compare.Note = spreadNote(a, b)
+			}
+		}
+		if err != nil {
+			fmt.Printf("summarizeSlot error: %v\n", err)
+			compare.Note = err.Error()
+		}
+	}
+
+	if page, ok := renderPage(w, tmplCompare, compare); ok {
+		w.Write(page)
+	}
+}
+
+// spreadNote tells which graph is more spread out by the mean distance of its vertices
+// from their centroid
+func spreadNote(a, b *GraphSummaryT) string {
+	switch {
+	case lessDistance(b.spread, a.spread):
+		return fmt.Sprintf("%s is more spread out, its vertices are %.1f times as far from their centroid", a.Slot, a.spread/b.spread)
+	case lessDistance(a.spread, b.spread):
+		return fmt.Sprintf("%s is more spread out, its vertices are %.1f times as far from their centroid", b.Slot, b.spread/a.spread)
+	}
+	return "both graphs are equally spread out"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// components labels the connected components of the adjacency list by union-find.
+// The adjacency list has the edges the SP search may use, so a sparse kNN or edge
+// probability graph, the clip rectangle and the exclusion zone can split it.  It
+// returns the component of each vertex, numbered from the lowest vertex, and the
+// number of vertices of each component.
+func (dsp *DijksraSP) components() ([]int, []int) {
+	parent := make([]int, len(dsp.adj))
+	for v := range parent {
+		parent[v] = v
+	}
+	// root of the tree of v, halving the path on the way
+	find := func(v int) int {
+		for parent[v] != v {
+			parent[v] = parent[parent[v]]
+			v = parent[v]
+		}
+		return v
+	}
+	for _, edges := range dsp.adj {
+		for _, e := range edges {
+			a, b := find(e.v), find(e.w)
+			if a < b {
+				parent[b] = a
+			} else if b < a {
+				parent[a] = b
+			}
+		}
+	}
+
+	component := make([]int, len(parent))
+	sizes := make([]int, 0)
+	label := make(map[int]int)
+	for v := range parent {
+		root := find(v)
+		c, ok := label[root]
+		if !ok {
+			c = len(sizes)
+			label[root] = c
+			sizes = append(sizes, 0)
+		}
+		component[v] = c
+		sizes[c]++
+	}
+	return component, sizes
+}
+
+// checkComponents shows the number of connected components of the adjacency list
+// and their sizes from largest to smallest.  A source and target in different
+// components have no SP, so the search is skipped with an error.
+func (dsp *DijksraSP) checkComponents() error {
+	component, sizes := dsp.components()
+	dsp.plot.Components = strconv.Itoa(len(sizes))
+	sorted := append([]int(nil), sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	list := make([]string, len(sorted))
+	for i, size := range sorted {
+		list[i] = strconv.Itoa(size)
+	}
+	dsp.plot.ComponentSizes = strings.Join(list, ",")
+
+	source, target := component[dsp.source], component[dsp.target]
+	if source != target {
+		return withCode(apiUnreachable, fmt.Errorf("source vertex %d and target vertex %d are in different components of %d and %d vertices, there is no SP",
+			dsp.source, dsp.target, sizes[source], sizes[target]))
+	}
+	return nil
+}
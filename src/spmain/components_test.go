@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"math/cmplx"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestComponents labels the components of two triangles and a lone vertex and checks
+// their sizes, and that an SP query between the triangles stops before the search
+// with a different components error
+func TestComponents(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(2, 1), complex(1, 2), complex(8, 8), complex(9, 8), complex(8, 9), complex(5, 5)}
+	graph := make([][]float64, len(location))
+	for v := range graph {
+		graph[v] = make([]float64, len(location))
+		for w := range graph[v] {
+			graph[v][w] = infinity
+			if v != w && v/3 == w/3 {
+				graph[v][w] = cmplx.Abs(location[v] - location[w])
+			}
+		}
+	}
+	newSP := func() *DijksraSP {
+		return &DijksraSP{plot: &PlotT{}, location: location, graph: graph, mst: make(MST, len(location)),
+			Endpoints: &bounds, metric: metricEuclidean}
+	}
+
+	dsp := newSP()
+	err := dsp.findSP(postForm(url.Values{"sourcevert": {"0"}, "targetvert": {"4"}, "fullgraph": {"on"}}))
+	if err == nil || !strings.Contains(err.Error(), "different components") || apiCode(err) != apiUnreachable {
+		t.Fatalf("SP between the triangles gave error %v", err)
+	}
+	if dsp.settled != 0 {
+		t.Fatalf("SP between the triangles settled %d vertices", dsp.settled)
+	}
+	if dsp.plot.Components != "3" || dsp.plot.ComponentSizes != "3,3,1" {
+		t.Fatalf("components %s of sizes %s, want 3 of sizes 3,3,1", dsp.plot.Components, dsp.plot.ComponentSizes)
+	}
+
+	dsp = newSP()
+	if err := dsp.findSP(postForm(url.Values{"sourcevert": {"3"}, "targetvert": {"5"}, "fullgraph": {"on"}})); err != nil {
+		t.Fatal(err)
+	}
+	if dsp.distTo[5] != graph[3][5] {
+		t.Fatalf("SP in a triangle has distance %g, want %g", dsp.distTo[5], graph[3][5])
+	}
+}
+
+// TestDisconnectedMST asks /api/mst for the MST of custom edges that leave a vertex
+// out.  The graph of the request is not connected, it is an input error.
+func TestDisconnectedMST(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-disconnected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"slot": {"test-disconnected"}, "edgesonly": {"on"}, "edgeweights": {"0,1,1"}}
+	r := httptest.NewRequest(http.MethodPost, patternMST, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handleMST(w, r)
+	var apiErr APIErrorT
+	if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || apiErr.Error.Code != apiInvalidInput || !strings.Contains(apiErr.Error.Message, "not connected") {
+		t.Fatalf("MST of a disconnected graph answered %d %+v", w.Code, apiErr.Error)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"net/http"
+)
+
+// Shortcut replaces a maximal chain of degree-2 vertices with a single weighted edge
+type Shortcut struct {
+	Edge             // chain end vertices, both are kept in the reduced graph
+	inner    []int   // internal chain vertices in order from v to w
+	distance float64 // total distance of the chain edges
+}
+
+// contract reduces the adjacency list by replacing each maximal chain of degree-2
+// vertices with a Shortcut.  The source and target are never contracted.  It returns
+// the reduced adjacency list indexed by the kept vertices and the number of kept vertices.
+func (dsp *DijksraSP) contract() ([][]*Shortcut, int) {
+	vertices := len(dsp.adj)
+	keep := make([]bool, vertices)
+	for v := range dsp.adj {
+		keep[v] = len(dsp.adj[v]) != 2 || v == dsp.source || v == dsp.target
+	}
+
+	radj := make([][]*Shortcut, vertices)
+	visited := make(map[*Edge]bool)
+
+	// other returns the vertex on the opposite end of the edge from v
+	other := func(e *Edge, v int) int {
+		if e.v == v {
+			return e.w
+		}
+		return e.v
+	}
+
+	// walk follows the chain starting at kept vertex v along edge e until another kept vertex
+	walk := func(v int, e *Edge) {
+		sc := &Shortcut{Edge: Edge{v: v}, inner: make([]int, 0)}
+		prev := v
+		for {
+			visited[e] = true
+			w := other(e, prev)
+			sc.distance += dsp.graph[prev][w]
+			if keep[w] {
+				sc.w = w
+				break
+			}
+			sc.inner = append(sc.inner, w)
+			// a degree-2 vertex has exactly one edge we did not arrive on
+			next := dsp.adj[w][0]
+			if next == e {
+				next = dsp.adj[w][1]
+			}
+			prev, e = w, next
+		}
+		radj[sc.v] = append(radj[sc.v], sc)
+		if sc.w != sc.v {
+			radj[sc.w] = append(radj[sc.w], sc)
+		}
+	}
+
+	for v := range dsp.adj {
+		if !keep[v] {
+			continue
+		}
+		for _, e := range dsp.adj[v] {
+			if !visited[e] {
+				walk(v, e)
+			}
+		}
+	}
+
+	// A cycle of degree-2 vertices has no kept vertex, so keep one of its vertices
+	for v := range dsp.adj {
+		if keep[v] || visited[dsp.adj[v][0]] {
+			continue
+		}
+		keep[v] = true
+		walk(v, dsp.adj[v][0])
+	}
+
+	kept := 0
+	for _, k := range keep {
+		if k {
+			kept++
+		}
+	}
+
+	return radj, kept
+}
+
+// findSPContracted constructs the shortest path from source to target by running
+// Dijkstra on the contracted graph and expanding the shortcuts on the path.  Equal
+// distances are broken by the hops of the expanded path in the order of the hop
+// order, as the search of the uncontracted graph does.
+func (dsp *DijksraSP) findSPContracted(r *http.Request) error {
+	if err := dsp.parseSourceTarget(r); err != nil {
+		return err
+	}
+	if err := dsp.parseHopOrder(r); err != nil {
+		return err
+	}
+	// The degree-2 chains are chains of the MST
+	if r.PostFormValue("fullgraph") == "on" {
+		dsp.plot.FullGraph = "checked"
+		return withCode(apiInvalidInput, fmt.Errorf("contraction applies to the MST, uncheck the full graph search"))
+	}
+
+	vertices := len(dsp.location)
+	dsp.buildAdjacency()
+	// Source and target in different components have no SP to search for
+	if err := dsp.checkComponents(); err != nil {
+		return err
+	}
+	radj, kept := dsp.contract()
+
+	edges, shortcuts := 0, 0
+	for v := range dsp.adj {
+		edges += len(dsp.adj[v])
+		for _, sc := range radj[v] {
+			// count each shortcut once, a loop is only listed at one end
+			if sc.v == v {
+				shortcuts++
+			}
+		}
+	}
+	dsp.plot.ContractVertices = fmt.Sprintf("%d -> %d", vertices, kept)
+	dsp.plot.ContractEdges = fmt.Sprintf("%d -> %d", edges/2, shortcuts)
+
+	// Run Dijkstra on the reduced graph, items are inserted again instead of updated.
+	// A shortcut counts the hops of its chain.
+	distTo := make([]float64, vertices)
+	for i := range distTo {
+		distTo[i] = infinity
+	}
+	hopsTo := make([]int, vertices)
+	settled := make([]bool, vertices)
+	via := make([]*Shortcut, vertices)
+	pq := newPriorityQueue()
+	distTo[dsp.source] = 0.0
+	heap.Push(&pq, &Item{Edge: Edge{v: dsp.source, w: dsp.source}, distance: 0.0})
+	dsp.settled, dsp.relaxed = 0, 0
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(*Item)
+		v := item.w
+		if settled[v] {
+			continue
+		}
+		settled[v] = true
+		dsp.settled++
+		if v == dsp.target {
+			break
+		}
+		for _, sc := range radj[v] {
+			dsp.relaxed++
+			w := sc.w
+			if w == v {
+				w = sc.v
+			}
+			newDistance := distTo[v] + sc.distance
+			newHops := hopsTo[v] + len(sc.inner) + 1
+			// An equal distance is better with the preferred hops if w is not settled
+			tie := !lessDistance(distTo[w], newDistance) && !settled[w] &&
+				dsp.hopOrder*newHops < dsp.hopOrder*hopsTo[w]
+			if lessDistance(newDistance, distTo[w]) || tie {
+				distTo[w] = newDistance
+				hopsTo[w] = newHops
+				via[w] = sc
+				heap.Push(&pq, &Item{Edge: Edge{v: v, w: w}, distance: newDistance, rank: dsp.hopOrder * newHops})
+			}
+		}
+	}
+
+	dsp.edgeTo = make([]*Edge, vertices)
+	dsp.distTo = make([]float64, vertices)
+	for i := range dsp.distTo {
+		dsp.distTo[i] = infinity
+	}
+	if distTo[dsp.target] == infinity {
+		return dsp.checkExclusion()
+	}
+
+	// Expand the shortcuts from target back to source into the vertex path
+	path := []int{dsp.target}
+	for w := dsp.target; w != dsp.source; {
+		sc := via[w]
+		chain := sc.inner
+		v := sc.v
+		if sc.v == w {
+			v = sc.w
+		} else {
+			// walk the chain from w to v, the reverse of its stored order
+			chain = make([]int, len(sc.inner))
+			for i, u := range sc.inner {
+				chain[len(sc.inner)-1-i] = u
+			}
+		}
+		path = append(path, chain...)
+		path = append(path, v)
+		w = v
+	}
+
+	// Fill in edgeTo and distTo along the expanded path from source to target
+	dsp.distTo[dsp.source] = 0.0
+	for i := len(path) - 1; i > 0; i-- {
+		v, w := path[i], path[i-1]
+		dsp.edgeTo[w] = &Edge{v: v, w: w}
+		dsp.distTo[w] = dsp.distTo[v] + dsp.graph[v][w]
+	}
+
+	return dsp.checkExclusion()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math/rand"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// spPath returns the vertices of the SP found by a search from source to target
+func spPath(dsp *DijksraSP) []int {
+	path := []int{dsp.target}
+	for e := dsp.edgeTo[dsp.target]; e != nil; e = dsp.edgeTo[e.v] {
+		path = append([]int{e.v}, path...)
+	}
+	return path
+}
+
+// TestContracted compares the SP of the contracted graph to the SP of findSP on the
+// MST of seeded random graphs, with and without a hop order.  Both have the same
+// distance and path and count the components.  A bad hop order is an input error.
+func TestContracted(t *testing.T) {
+	for s := int64(1); s <= searchSeeds; s++ {
+		rng := rand.New(rand.NewSource(s))
+		bounds := Endpoints{xmin: 0, ymin: 0, xmax: 100, ymax: 100}
+		location := make([]complex128, searchVertices)
+		for i := range location {
+			location[i] = complex(100*rng.Float64(), 100*rng.Float64())
+		}
+		primmst := &PrimMST{plot: &PlotT{}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		for pair := 0; pair < searchPairs; pair++ {
+			source, target := rng.Intn(searchVertices), rng.Intn(searchVertices)
+			if source == target {
+				continue
+			}
+			form := url.Values{"sourcevert": {strconv.Itoa(source)}, "targetvert": {strconv.Itoa(target)},
+				"hoporder": {[]string{"", hopsFewest, hopsMost}[pair%3]}}
+			want := newDijkstraSP(primmst)
+			want.plot = &PlotT{}
+			if err := want.findSP(postForm(form)); err != nil {
+				t.Fatal(err)
+			}
+			got := newDijkstraSP(primmst)
+			got.plot = &PlotT{}
+			if err := got.findSPContracted(postForm(form)); err != nil {
+				t.Fatal(err)
+			}
+			d, dWant := got.distTo[target], want.distTo[target]
+			if lessDistance(d, dWant) || lessDistance(dWant, d) {
+				t.Fatalf("seed %d source %d target %d: contracted distance %g, findSP %g", s, source, target, d, dWant)
+			}
+			if p, pWant := spPath(got), spPath(want); len(p) != len(pWant) {
+				t.Fatalf("seed %d source %d target %d: contracted path %v, findSP %v", s, source, target, p, pWant)
+			} else {
+				for i := range p {
+					if p[i] != pWant[i] {
+						t.Fatalf("seed %d source %d target %d: contracted path %v, findSP %v", s, source, target, p, pWant)
+					}
+				}
+			}
+			if got.plot.Components != "1" {
+				t.Fatalf("seed %d: contracted search counted %q components of the MST", s, got.plot.Components)
+			}
+		}
+
+		dsp := newDijkstraSP(primmst)
+		dsp.plot = &PlotT{}
+		err := dsp.findSPContracted(postForm(url.Values{"sourcevert": {"0"}, "targetvert": {"1"}, "hoporder": {"any"}}))
+		if apiCode(err) != apiInvalidInput {
+			t.Fatalf("contracted search with a bad hop order gave %v, want %s", err, apiInvalidInput)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+)
+
+// plotCritical highlights the longest SP edge found by plotSP, the critical link whose
+// failure would most disrupt the route, and finds the backup route without it.
+func (dsp *DijksraSP) plotCritical() error {
+	if dsp.critical == nil {
+		return fmt.Errorf("distance to vertex %d not found", dsp.target)
+	}
+	v, w := dsp.critical.v, dsp.critical.w
+	length := dsp.graph[v][w]
+
+	// Search the adjacency list without the critical link
+	adj := dsp.adj
+	dsp.adj = make([][]*Edge, len(adj))
+	for u := range adj {
+		dsp.adj[u] = make([]*Edge, 0, len(adj[u]))
+		for _, e := range adj[u] {
+			if (e.v == v && e.w == w) || (e.v == w && e.w == v) {
+				continue
+			}
+			dsp.adj[u] = append(dsp.adj[u], e)
+		}
+	}
+	distTo, prev := dsp.shortestFrom(dsp.source)
+	dsp.adj = adj
+
+	if distTo[dsp.target] == infinity {
+		// The SP of the MST mode uses only tree edges, all of them are bridges
+		tree := "spanning tree"
+		if dsp.fullGraph {
+			tree = "graph"
+		}
+		dsp.plot.CriticalBackup = "no backup route, the link is a bridge of the " + tree
+	} else {
+		path := make([]int, 0)
+		for u := dsp.target; u != -1; u = prev[u] {
+			path = append(path, u)
+		}
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		dsp.plotPath(path, "edgeBackup")
+		dsp.plot.CriticalBackup = fmt.Sprintf("backup route %s, added cost %s",
+			dsp.plot.withUnits(fmt.Sprintf("%.2f", distTo[dsp.target])),
+			dsp.plot.withUnits(fmt.Sprintf("%.2f", distTo[dsp.target]-dsp.distTo[dsp.target])))
+	}
+
+	// Draw the critical link over the SP and restore the SP source Blue and target Red
+	dsp.plot.drawEdge(dsp.location[v], dsp.location[w], "edgeCritical")
+	dsp.plot.setMarker(dsp.location[dsp.source], "vertexSP1")
+	dsp.plot.setMarker(dsp.location[dsp.target], "vertexSP2")
+
+	dsp.plot.CriticalLink = fmt.Sprintf("%d - %d", v, w)
+	dsp.plot.CriticalLength = dsp.plot.withUnits(fmt.Sprintf("%.2f", length))
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math/cmplx"
+	"strings"
+	"testing"
+)
+
+// TestCritical searches a full graph of a quadrilateral with a pendant vertex.  The
+// SP across the quadrilateral has its longest edge as the critical link and the other
+// side as the backup route.  The edge to the pendant vertex is a bridge of the graph,
+// and in the MST mode every link is a bridge of the spanning tree.
+func TestCritical(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(5, 1), complex(5, 4), complex(1, 5), complex(9, 9)}
+	graph := make([][]float64, len(location))
+	for v := range graph {
+		graph[v] = make([]float64, len(location))
+		for w := range graph[v] {
+			graph[v][w] = infinity
+		}
+	}
+	for _, e := range [][2]int{{0, 1}, {1, 2}, {0, 3}, {3, 2}, {2, 4}} {
+		graph[e[0]][e[1]] = cmplx.Abs(location[e[0]] - location[e[1]])
+		graph[e[1]][e[0]] = graph[e[0]][e[1]]
+	}
+	// critical plots the grid of the graph and the SP from vertex 0 to the target on the
+	// full graph or the MST, then its critical link
+	critical := func(fullGraph bool, target int) *PlotT {
+		primmst := &PrimMST{plot: &PlotT{Units: "km", view: &bounds}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotGrid(); err != nil {
+			t.Fatal(err)
+		}
+		dsp := newDijkstraSP(primmst)
+		if fullGraph {
+			dsp.graph, dsp.fullGraph = graph, true
+		}
+		dsp.source, dsp.target = 0, target
+		dsp.searchSP()
+		if err := dsp.plotSP(); err != nil {
+			t.Fatal(err)
+		}
+		if err := dsp.plotCritical(); err != nil {
+			t.Fatal(err)
+		}
+		return dsp.plot
+	}
+
+	// 0-1-2 is 7 long, the backup 0-3-2 is 4 + sqrt(17)
+	plot := critical(true, 2)
+	if plot.CriticalLink != "0 - 1" || plot.CriticalLength != "4.00 km" ||
+		plot.CriticalBackup != "backup route 8.12 km, added cost 1.12 km" {
+		t.Fatalf("critical link %s of length %s with %q, want 0 - 1 of length 4.00 km and backup route 8.12 km",
+			plot.CriticalLink, plot.CriticalLength, plot.CriticalBackup)
+	}
+	// The edge 2-4 is the longest and the only way to 4
+	plot = critical(true, 4)
+	if plot.CriticalLink != "2 - 4" || plot.CriticalBackup != "no backup route, the link is a bridge of the graph" {
+		t.Fatalf("critical link %s with %q, want 2 - 4 as a bridge of the graph", plot.CriticalLink, plot.CriticalBackup)
+	}
+	plot = critical(false, 2)
+	if !strings.HasSuffix(plot.CriticalBackup, "a bridge of the spanning tree") {
+		t.Fatalf("critical link %s of the MST with %q, want a bridge of the spanning tree", plot.CriticalLink, plot.CriticalBackup)
+	}
+}
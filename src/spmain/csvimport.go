@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseCSVFloats parses the comma-separated values of the line as finite floats
+func parseCSVFloats(line string) ([]float64, error) {
+	values := strings.Split(line, ",")
+	floats := make([]float64, len(values))
+	for i, str := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("value %s is not finite", str)
+		}
+		floats[i] = f
+	}
+	return floats, nil
+}
+
+// importCSV reads the vertex locations from an uploaded coordinate CSV in the format
+// of the vertex file: a bounds header line "xmin,ymin,xmax,ymax" followed by "x,y"
+// lines.  A malformed line or a vertex outside the bounds is skipped and counted in
+// the import summary, a bad header is an error.
+func (p *PrimMST) importCSV(r io.Reader) error {
+	input := bufio.NewScanner(r)
+	p.Endpoints = nil
+	p.location = make([]complex128, 0)
+	skipped := 0
+	for line := 1; input.Scan(); line++ {
+		text := strings.TrimSpace(input.Text())
+		if len(text) == 0 {
+			continue
+		}
+		values, err := parseCSVFloats(text)
+
+		// The first line is the bounds header
+		if p.Endpoints == nil {
+			if err != nil || len(values) != 4 {
+				return fmt.Errorf("CSV line %d bounds header %q needs xmin,ymin,xmax,ymax", line, text)
+			}
+			if p.Endpoints, err = newEndpoints(values[0], values[1], values[2], values[3]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil || len(values) != 2 {
+			fmt.Printf("CSV line %d %q needs x,y\n", line, text)
+			skipped++
+			continue
+		}
+		x, y := values[0], values[1]
+		if x < p.xmin || x > p.xmax || y < p.ymin || y > p.ymax {
+			fmt.Printf("CSV line %d vertex %g,%g is outside the bounds\n", line, x, y)
+			skipped++
+			continue
+		}
+		p.location = append(p.location, complex(x, y))
+	}
+	if err := input.Err(); err != nil {
+		return err
+	}
+	if p.Endpoints == nil {
+		return fmt.Errorf("CSV file is empty, it needs the xmin,ymin,xmax,ymax bounds header")
+	}
+	if len(p.location) < 2 {
+		return fmt.Errorf("CSV file has %d valid vertices, at least 2 are needed", len(p.location))
+	}
+
+	p.plot.Imported = fmt.Sprintf("CSV import: %d vertices, %d malformed lines skipped", len(p.location), skipped)
+	return nil
+}
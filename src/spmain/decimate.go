@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// parseMaxPlot gets the largest number of vertices to draw from the HTML form.  The MST
+// and SP are still found on every vertex, only the drawing is decimated.
+func (p *PrimMST) parseMaxPlot(r *http.Request) error {
+	str := r.PostFormValue("maxplot")
+	if len(str) == 0 {
+		return nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return err
+	}
+	if n < 1 {
+		return fmt.Errorf("max plotted vertices %d must be positive", n)
+	}
+	p.plot.MaxPlot = str
+	p.decimate(n)
+	return nil
+}
+
+// decimate keeps every k-th vertex so at most n of them are drawn, k is the smallest
+// stride that does.  The MST start vertex 0 is always kept.  The SP is drawn in full.
+func (p *PrimMST) decimate(n int) {
+	if len(p.location) <= n {
+		return
+	}
+	stride := (len(p.location) + n - 1) / n
+	p.shown = make([]bool, len(p.location))
+	kept := 0
+	for v := 0; v < len(p.location); v += stride {
+		p.shown[v] = true
+		kept++
+	}
+	p.plot.Decimated = fmt.Sprintf("drew %d of %d vertices, 1 in %d", kept, len(p.location), stride)
+}
+
+// isShown reports whether vertex v is drawn
+func (p *PrimMST) isShown(v int) bool {
+	return p.shown == nil || p.shown[v]
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+const defaultDemoPairs = 3 // random source/target pairs drawn when none are given
+
+// demoClasses are the CSS classes of the demo paths, one color per pair
+var demoClasses = []string{"edgeDemo0", "edgeDemo1", "edgeDemo2", "edgeDemo3", "edgeDemo4", "edgeDemo5"}
+
+// Type to contain one random demo pair of the distances table
+type DemoT struct {
+	Class    string // CSS class of the drawn path
+	Source   string // source vertex of the pair
+	Target   string // target vertex of the pair
+	Distance string // SP distance of the pair
+}
+
+// parseDemoPairs gets the number of demo pairs from the HTML form
+func (dsp *DijksraSP) parseDemoPairs(r *http.Request) (int, error) {
+	str := r.PostFormValue("demopairs")
+	if len(str) == 0 {
+		dsp.plot.DemoPairs = strconv.Itoa(defaultDemoPairs)
+		return defaultDemoPairs, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return 0, err
+	}
+	if n < 1 || n > len(demoClasses) {
+		return 0, fmt.Errorf("demo pairs %d must be 1-%d", n, len(demoClasses))
+	}
+	dsp.plot.DemoPairs = str
+	return n, nil
+}
+
+// findDemo picks n distinct random source/target pairs of the vertices in the clip
+// rectangle, finds the SP of each on the saved graph and draws them in their own color.
+// The random numbers come from rng, so the seed of the graph repeats the pairs.
+func (dsp *DijksraSP) findDemo(n int, rng *rand.Rand) error {
+	candidates := make([]int, 0, len(dsp.location))
+	for v := range dsp.location {
+		if dsp.inClip(v) {
+			candidates = append(candidates, v)
+		}
+	}
+	if pairs := len(candidates) * (len(candidates) - 1) / 2; pairs < n {
+		return fmt.Errorf("demo needs %d distinct pairs, the graph has %d", n, pairs)
+	}
+
+	// Search a copy so the SP of the form keeps its edgeTo and distTo
+	demo := *dsp
+	type pair struct{ v, w int }
+	used := make(map[pair]bool)
+	dsp.plot.Demo = make([]DemoT, 0, n)
+	for len(dsp.plot.Demo) < n {
+		s := candidates[rng.Intn(len(candidates))]
+		t := candidates[rng.Intn(len(candidates))]
+		key := pair{s, t}
+		if s > t {
+			key = pair{t, s}
+		}
+		if s == t || used[key] {
+			continue
+		}
+		used[key] = true
+
+		class := demoClasses[len(dsp.plot.Demo)]
+		demo.source, demo.target = s, t
+		demo.searchSP()
+		entry := DemoT{Class: class, Source: strconv.Itoa(s), Target: strconv.Itoa(t), Distance: "not reachable"}
+		if demo.distTo[t] < infinity {
+			demo.plotPath(demo.pathVertices(), class)
+			entry.Distance = dsp.plot.withUnits(fmt.Sprintf("%.2f", demo.distTo[t]))
+		}
+		dsp.plot.Demo = append(dsp.plot.Demo, entry)
+	}
+
+	// Mark the pair endpoints after all the paths so no path covers them
+	for _, entry := range dsp.plot.Demo {
+		s, _ := strconv.Atoi(entry.Source)
+		t, _ := strconv.Atoi(entry.Target)
+		dsp.plot.setMarker(dsp.location[s], "vertexSP1")
+		dsp.plot.setMarker(dsp.location[t], "vertexSP2")
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+)
+
+const (
+	maxDensitySide  = 2048 // largest width or height of the density map in pixels
+	maxDensityTries = 1000 // rejected samples allowed per vertex before giving up
+)
+
+// densityVertices generates verts vertices in the endpoints with a probability
+// proportional to the darkness of the uploaded grayscale PNG, which is stretched over
+// the endpoints with its top row at ymax.  Candidate locations are drawn uniformly and
+// accepted with the darkness of their pixel, so the random numbers come from the seeded
+// generator rng and its seed repeats the layout.  An all-white map is uniform.
+func (p *PrimMST) densityVertices(r io.Reader, verts int, rng *rand.Rand) error {
+	img, err := png.Decode(r)
+	if err != nil {
+		return fmt.Errorf("density map: %v", err)
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxDensitySide || height > maxDensitySide {
+		return fmt.Errorf("density map is %dx%d pixels, each side must be 1-%d", width, height, maxDensitySide)
+	}
+
+	// Darker pixels weigh more, white weighs nothing
+	weight := make([]float64, width*height)
+	maxWeight := 0.0
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			gray := color.Gray16Model.Convert(img.At(bounds.Min.X+col, bounds.Min.Y+row)).(color.Gray16)
+			w := 1.0 - float64(gray.Y)/0xffff
+			weight[row*width+col] = w
+			if w > maxWeight {
+				maxWeight = w
+			}
+		}
+	}
+
+	delx := p.xmax - p.xmin
+	dely := p.ymax - p.ymin
+	p.location = make([]complex128, verts)
+	if maxWeight == 0 {
+		for i := range p.location {
+			p.location[i] = complex(p.xmin+delx*rng.Float64(), p.ymin+dely*rng.Float64())
+		}
+		p.plot.Imported = fmt.Sprintf("Density map: %dx%d pixels, all white so the vertices are uniform", width, height)
+		return nil
+	}
+
+	tries := 0
+	for i := 0; i < verts; {
+		if tries == maxDensityTries*verts {
+			return fmt.Errorf("density map is too light, %d of %d vertices placed", i, verts)
+		}
+		tries++
+		x := p.xmin + delx*rng.Float64()
+		y := p.ymin + dely*rng.Float64()
+		col := int(float64(width) * (x - p.xmin) / delx)
+		row := int(float64(height) * (p.ymax - y) / dely)
+		if col == width {
+			col--
+		}
+		if row == height {
+			row--
+		}
+		if rng.Float64()*maxWeight < weight[row*width+col] {
+			p.location[i] = complex(x, y)
+			i++
+		}
+	}
+	p.plot.Imported = fmt.Sprintf("Density map: %dx%d pixels, %.1f%% of the samples accepted",
+		width, height, 100*float64(verts)/float64(tries))
+
+	return nil
+}
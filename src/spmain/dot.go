@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DOT edge styles, matching the colors of the grid
+const (
+	dotEdgeStyle = `color="#dddddd"`                         // graph edge not in the MST
+	dotMSTStyle  = `color="gray40", style=bold, class="mst"` // MST edge
+	dotSPStyle   = `color="orange", penwidth=3, class="sp"`  // SP edge
+)
+
+// dotQuote returns the DOT quoted string of s
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// writeDOT writes the graph in the GraphViz DOT language.  The vertices are pinned
+// at their locations and the edge weights are the distances of the graph.  Only the
+// MST edges are written unless all is set, the SP edges of path are always written.
+func (dsp *DijksraSP) writeDOT(w io.Writer, all bool, path []int) {
+	// Edges are keyed by their vertices in increasing order
+	key := func(v, w int) Edge {
+		if v > w {
+			v, w = w, v
+		}
+		return Edge{v: v, w: w}
+	}
+	inMST := make(map[Edge]bool)
+	for _, e := range dsp.mst.edges() {
+		inMST[key(e.v, e.w)] = true
+	}
+	inSP := make(map[Edge]bool)
+	for i := 1; i < len(path); i++ {
+		inSP[key(path[i-1], path[i])] = true
+	}
+
+	fmt.Fprintln(w, "graph dijkstrasp {")
+	fmt.Fprintln(w, "\tnode [shape=point];")
+	for v, z := range dsp.location {
+		fmt.Fprintf(w, "\t%d [pos=\"%g,%g!\"", v, real(z), imag(z))
+		if len(dsp.names) > 0 {
+			fmt.Fprintf(w, ", xlabel=%s", dotQuote(vertexName(dsp.names, v)))
+		}
+		fmt.Fprintln(w, "];")
+	}
+	edge := func(e Edge) {
+		style := dotEdgeStyle
+		if inSP[e] {
+			style = dotSPStyle
+		} else if inMST[e] {
+			style = dotMSTStyle
+		}
+		fmt.Fprintf(w, "\t%d -- %d [weight=%g, %s];\n", e.v, e.w, dsp.graph[e.v][e.w], style)
+	}
+	if all {
+		for v := range dsp.graph {
+			for u := v + 1; u < len(dsp.graph); u++ {
+				if dsp.graph[v][u] < infinity {
+					edge(Edge{v: v, w: u})
+				}
+			}
+		}
+	} else {
+		for _, e := range dsp.mst.edges() {
+			edge(key(e.v, e.w))
+		}
+		// A full graph SP can leave the MST
+		for i := 1; i < len(path); i++ {
+			if e := key(path[i-1], path[i]); !inMST[e] {
+				edge(e)
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// HTTP handler for /export/dot connections.  It writes the saved graph, its MST and
+// the SP between sourcevert and targetvert, if given, as GraphViz DOT for neato or
+// fdp, downloaded as an attachment with download=1.  edges=graph writes every edge
+// instead of only the MST.
+func handleDOT(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+	edges := r.FormValue("edges")
+	if len(edges) > 0 && edges != "mst" && edges != "graph" {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("edges %q must be mst or graph", edges))
+		return
+	}
+
+	dsp := newDijkstraSP(primmst)
+	var path []int
+	if len(r.FormValue("sourcevert")) > 0 || len(r.FormValue("targetvert")) > 0 {
+		if err := dsp.findSP(r); err != nil {
+			writeAPIError(w, apiCode(err), err.Error())
+			return
+		}
+		if path = dsp.pathVertices(); path == nil {
+			writeAPIError(w, apiNoPath, fmt.Sprintf("target vertex %d unreachable from source vertex %d", dsp.target, dsp.source))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	if r.FormValue("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=dijkstrasp.dot")
+	}
+	dsp.writeDOT(w, edges == "graph", path)
+}
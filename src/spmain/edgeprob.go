@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// applyEdgeProb keeps each edge of the complete graph with the probability edgeprob
+// of the HTML form, an Erdős–Rényi random graph with the metric distances as weights.
+// The edges are drawn from a generator with the seed of the vertex layout, so the SP
+// requests of the saved graph keep the same edges; a graph without a seed, such as a
+// preset, uses the deterministic seed.
+func (p *PrimMST) applyEdgeProb(r *http.Request) error {
+	str := r.PostFormValue("edgeprob")
+	if len(str) == 0 {
+		return nil
+	}
+	p.plot.EdgeProb = str
+	prob, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		fmt.Printf("String %s conversion to float error: %v\n", str, err)
+		return err
+	}
+	if prob < 0 || prob > 1 {
+		return fmt.Errorf("edge probability %g must be in [0,1]", prob)
+	}
+
+	rng, err := formRand(r)
+	if err != nil {
+		return err
+	}
+
+	edges := 0
+	for v := range p.graph {
+		for w := v + 1; w < len(p.graph); w++ {
+			if rng.Float64() < prob {
+				edges++
+				continue
+			}
+			p.graph[v][w] = infinity
+			p.graph[w][v] = infinity
+		}
+	}
+	p.plot.EdgeProbEdges = strconv.Itoa(edges)
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"strconv"
+)
+
+const minExclusionPoints = 16 // fewest points drawing the exclusion circle
+
+// Type to hold the circular exclusion zone of the SP search
+type Exclusion struct {
+	center complex128 // center x,y of the circle
+	radius float64    // radius of the circle
+}
+
+// parseExclusion gets the optional circular exclusion zone from the HTML form.  The SP
+// search leaves out the vertices inside it and the edges crossing it.
+func (dsp *DijksraSP) parseExclusion(r *http.Request) error {
+	names := []string{"excludex", "excludey", "excluderadius"}
+	values := make([]float64, len(names))
+	set := 0
+	for i, name := range names {
+		str := r.PostFormValue(name)
+		if len(str) == 0 {
+			continue
+		}
+		var err error
+		if values[i], err = strconv.ParseFloat(str, 64); err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", str, err)
+			return err
+		}
+		set++
+	}
+	if set == 0 {
+		return nil
+	}
+	if set < len(names) {
+		return fmt.Errorf("exclusion zone needs center x, center y and radius")
+	}
+	if values[2] <= 0 {
+		return fmt.Errorf("exclusion radius %g must be positive", values[2])
+	}
+
+	dsp.exclusion = &Exclusion{center: complex(values[0], values[1]), radius: values[2]}
+	dsp.plot.ExcludeX = fmt.Sprintf("%.2f", values[0])
+	dsp.plot.ExcludeY = fmt.Sprintf("%.2f", values[1])
+	dsp.plot.ExcludeRadius = fmt.Sprintf("%.2f", values[2])
+
+	return nil
+}
+
+// crosses returns true if the line segment from a to b intersects the circle, which
+// includes a segment with an endpoint inside the circle.  The closest point of the
+// segment to the center is the projection of the center clamped to the segment.
+func (ex *Exclusion) crosses(a, b complex128) bool {
+	d := b - a
+	t := 0.0
+	if length2 := real(d)*real(d) + imag(d)*imag(d); length2 > 0 {
+		c := ex.center - a
+		t = (real(c)*real(d) + imag(c)*imag(d)) / length2
+		t = math.Max(0, math.Min(1, t))
+	}
+	closest := a + complex(t, 0)*d
+	return cmplx.Abs(closest-ex.center) <= ex.radius
+}
+
+// excluded returns true if the edge between v and w crosses the exclusion zone.  On a
+// torus the edge is the segment of the shortest wrapped displacement.
+func (dsp *DijksraSP) excluded(v, w int) bool {
+	if dsp.exclusion == nil {
+		return false
+	}
+	a, b := dsp.location[v], dsp.location[w]
+	if dsp.metric == metricToroidal && dsp.isWrapped(a, b) {
+		d := dsp.wrap(a, b)
+		return dsp.exclusion.crosses(a, a+d) || dsp.exclusion.crosses(b, b-d)
+	}
+	return dsp.exclusion.crosses(a, b)
+}
+
+// checkExclusion reports the source or target inside the exclusion zone and an
+// exclusion zone that disconnects them
+func (dsp *DijksraSP) checkExclusion() error {
+	if dsp.exclusion == nil {
+		return nil
+	}
+	for _, v := range []int{dsp.source, dsp.target} {
+		if cmplx.Abs(dsp.location[v]-dsp.exclusion.center) <= dsp.exclusion.radius {
+			return withCode(apiInvalidInput, fmt.Errorf("vertex %d is inside the exclusion zone", v))
+		}
+	}
+	if dsp.distTo[dsp.target] == infinity {
+		return withCode(apiUnreachable, fmt.Errorf("the exclusion zone disconnects source %d and target %d", dsp.source, dsp.target))
+	}
+	return nil
+}
+
+// plotExclusion draws the exclusion circle with about two points per grid cell of
+// its circumference.  CSS colors the circle.
+func (dsp *DijksraSP) plotExclusion() {
+	if dsp.exclusion == nil {
+		return
+	}
+	ex := dsp.exclusion
+	view := dsp.plot.view
+	lenEP := cmplx.Abs(complex(view.xmax-view.xmin, view.ymax-view.ymin))
+	points := int(2 * float64(dsp.plot.size()*dsp.plot.supersample) * 2 * math.Pi * ex.radius / lenEP)
+	if points < minExclusionPoints {
+		points = minExclusionPoints
+	}
+	for i := 0; i < points; i++ {
+		z := ex.center + cmplx.Rect(ex.radius, 2*math.Pi*float64(i)/float64(points))
+		dsp.plot.setCell(real(z), imag(z), "exclusion")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/thomasteplick/dijkstrasp/fixtures"
+)
+
+// TestFixtures searches the standard fixture graphs with searchSP on their full graph
+// and checks that it finds the reference SP of each fixture, its distance and path
+func TestFixtures(t *testing.T) {
+	standard, err := fixtures.Standard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range standard {
+		g := f.Graph
+		bounds := Endpoints{xmin: real(g.Vertices[0]), ymin: imag(g.Vertices[0]), xmax: real(g.Vertices[0]), ymax: imag(g.Vertices[0])}
+		graph := make([][]float64, len(g.Vertices))
+		for v, z := range g.Vertices {
+			graph[v] = make([]float64, len(g.Vertices))
+			for w := range graph[v] {
+				graph[v][w] = infinity
+			}
+			bounds.xmin, bounds.xmax = math.Min(bounds.xmin, real(z)), math.Max(bounds.xmax, real(z))
+			bounds.ymin, bounds.ymax = math.Min(bounds.ymin, imag(z)), math.Max(bounds.ymax, imag(z))
+		}
+		for _, e := range g.Edges {
+			graph[e.V][e.W], graph[e.W][e.V] = e.Weight, e.Weight
+		}
+		dsp := &DijksraSP{plot: &PlotT{}, location: g.Vertices, graph: graph, mst: make(MST, len(g.Vertices)),
+			Endpoints: &bounds, metric: metricEuclidean, source: f.SP.Source, target: f.SP.Target, fullGraph: true}
+		dsp.searchSP()
+		if d := dsp.distTo[dsp.target]; lessDistance(d, f.SP.Distance) || lessDistance(f.SP.Distance, d) {
+			t.Errorf("%s fixture: searchSP distance %g, reference %g", g.Name, d, f.SP.Distance)
+		}
+		if path := spPath(dsp); fmt.Sprint(path) != fmt.Sprint(f.SP.Vertices) {
+			t.Errorf("%s fixture: searchSP path %v, reference %v", g.Name, path, f.SP.Vertices)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden grids instead of comparing them")
+
+const fileGolden = "testdata/golden_%s.grid" // golden grid of a golden case
+
+// goldenCase is a plot rendered from a seeded random graph and compared to its golden grid
+type goldenCase struct {
+	name     string
+	vertices int
+	bounds   Endpoints
+	source   int
+	target   int
+	corners  bool // the first four vertices are the corners of the bounds
+}
+
+// goldenCases are the plots checked by TestGolden.  The negative bounds cases
+// put the MST start and the SP ends on the corners to check the markers at the edges.
+var goldenCases = []goldenCase{
+	{name: "small", vertices: 20, bounds: Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}, source: 1, target: 7},
+	{name: "mixed", vertices: 20, bounds: Endpoints{xmin: -50, ymin: -20, xmax: 50, ymax: 20}, source: 1, target: 3, corners: true},
+	{name: "negative", vertices: 20, bounds: Endpoints{xmin: -100, ymin: -40, xmax: 0, ymax: 0}, source: 2, target: 3, corners: true},
+	{name: "allnegative", vertices: 20, bounds: Endpoints{xmin: -50, ymin: -20, xmax: -10, ymax: -5}, source: 1, target: 2, corners: true},
+}
+
+// renderGolden draws the MST and SP of the golden case into the grid.  The vertices
+// come from their own generator with the deterministic seed, so the global random
+// numbers do not change the plot.  They are written and read back in the vertex file
+// format, so the plot also covers the round trip of the bounds and locations.
+func (gc *goldenCase) renderGolden() (*PlotT, error) {
+	rng := rand.New(rand.NewSource(deterministicSeed))
+	bounds := gc.bounds
+	location := make([]complex128, gc.vertices)
+	for i := range location {
+		x := bounds.xmin + (bounds.xmax-bounds.xmin)*rng.Float64()
+		y := bounds.ymin + (bounds.ymax-bounds.ymin)*rng.Float64()
+		location[i] = complex(x, y)
+	}
+	if gc.corners {
+		copy(location, []complex128{
+			complex(bounds.xmin, bounds.ymax), complex(bounds.xmax, bounds.ymin),
+			complex(bounds.xmin, bounds.ymin), complex(bounds.xmax, bounds.ymax),
+		})
+	}
+
+	var file bytes.Buffer
+	(&PrimMST{location: location, Endpoints: &bounds}).writeVertices(&file)
+	graphs, err := readGraphs(&file)
+	if err != nil {
+		return nil, err
+	}
+	bounds, location = *graphs[0].Endpoints, graphs[0].location
+	if bounds != gc.bounds || len(location) != gc.vertices {
+		return nil, fmt.Errorf("vertex file round trip changed the bounds to %v or the vertices to %d", bounds, len(location))
+	}
+
+	plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		return nil, err
+	}
+	if err := primmst.findMST(); err != nil {
+		return nil, err
+	}
+	if err := primmst.plotGrid(); err != nil {
+		return nil, err
+	}
+	if err := primmst.plotMST(nil); err != nil {
+		return nil, err
+	}
+
+	dijkstrasp := &DijksraSP{plot: plot, location: location, graph: primmst.graph, mst: primmst.mst,
+		Endpoints: &bounds, metric: metricEuclidean, source: gc.source, target: gc.target}
+	dijkstrasp.searchSP()
+	if err := dijkstrasp.plotSP(); err != nil {
+		return nil, err
+	}
+	plot.downsample()
+
+	return plot, nil
+}
+
+// encodeGrid writes the grid one row per line with a dot for an empty cell and a
+// letter for each class.  The letters are given in order of first appearance and are
+// listed in the first line as letter=class, so new classes do not change the grid.
+func encodeGrid(grid []string) ([]byte, error) {
+	letter := map[string]byte{"": '.'}
+	classes := make([]string, 0)
+	var cells bytes.Buffer
+	for row := 0; row < defaultGridSize; row++ {
+		for col := 0; col < defaultGridSize; col++ {
+			class := grid[row*defaultGridSize+col]
+			c, ok := letter[class]
+			if !ok {
+				if len(classes) == 26 {
+					return nil, fmt.Errorf("grid has more than 26 classes")
+				}
+				c = 'a' + byte(len(classes))
+				letter[class] = c
+				classes = append(classes, class)
+			}
+			cells.WriteByte(c)
+		}
+		cells.WriteByte('\n')
+	}
+
+	var b bytes.Buffer
+	for i, class := range classes {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%c=%s", 'a'+i, class)
+	}
+	b.WriteByte('\n')
+	b.Write(cells.Bytes())
+	return b.Bytes(), nil
+}
+
+// TestGolden renders every golden case and compares it to its golden grid.  With
+// -update the golden grids are written instead:
+//
+//	go test -run TestGolden -update
+func TestGolden(t *testing.T) {
+	for i := range goldenCases {
+		gc := &goldenCases[i]
+		t.Run(gc.name, func(t *testing.T) {
+			plot, err := gc.renderGolden()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := encodeGrid(plot.Grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := fmt.Sprintf(fileGolden, gc.name)
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("wrote %s", file)
+				return
+			}
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("grid drifted from %s, %s", file, firstDrift(got, want))
+			}
+		})
+	}
+}
+
+// firstDrift describes the first line that differs between the encoded grids.  Line
+// 0 lists the classes and line 1 is grid row 0.
+func firstDrift(got, want []byte) string {
+	gotRows := bufio.NewScanner(bytes.NewReader(got))
+	wantRows := bufio.NewScanner(bytes.NewReader(want))
+	for row := -1; ; row++ {
+		g, w := gotRows.Scan(), wantRows.Scan()
+		if !g || !w {
+			return fmt.Sprintf("row %d is missing", row)
+		}
+		if gotRows.Text() != wantRows.Text() && row < 0 {
+			return fmt.Sprintf("classes %q, golden has %q", gotRows.Text(), wantRows.Text())
+		}
+		if gotRows.Text() != wantRows.Text() {
+			for col := 0; col < len(gotRows.Text()) && col < len(wantRows.Text()); col++ {
+				if gotRows.Text()[col] != wantRows.Text()[col] {
+					return fmt.Sprintf("first difference at row %d column %d", row, col)
+				}
+			}
+			return fmt.Sprintf("row %d has %d columns, golden has %d", row, len(gotRows.Text()), len(wantRows.Text()))
+		}
+	}
+}
+
+// postForm returns the POST request of the Dijkstra SP form with the values
+func postForm(form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, patternDijkstraSP, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// tempGraphs saves the graph slots of the test in a temporary graphs directory, so
+// the test leaves no vertex files in the source tree
+func tempGraphs(t *testing.T) {
+	dir := dirGraphs
+	dirGraphs = t.TempDir()
+	t.Cleanup(func() { dirGraphs = dir })
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+const defaultGraphCacheSize = 16 // parsed graphs kept by the graph cache
+
+// mstOptions are the form values that change the graph distances or the MST
+var mstOptions = []string{"obstacles", "edgeprob", "seed", "edgeweights", "edgesonly", "knn", "startvert", "bellmanford"}
+
+// graphEntry is one graph of the graph cache, the parsed graph block of a vertex file
+// and the distances and MST of the last MST options used with it
+type graphEntry struct {
+	id     string      // graph ID, the vertex file, its version and the graph block
+	block  *GraphBlock // parsed graph block, shared read-only by the requests
+	blocks int         // graph blocks in the vertex file
+
+	mstKey    string      // MST options of the MST, empty if there is none
+	graph     [][]float64 // distances after the MST options were applied
+	mst       MST
+	start     int
+	neighbors [][]int
+	obstacles []Endpoints
+	plot      mstPlot // plot values set while finding the MST
+}
+
+// mstPlot are the plot values set by the MST phases of a request
+type mstPlot struct {
+	obstacles, edgeProb, edgeProbEdges, edgeWeights, edgesOnly, knn, startVert string
+}
+
+// graphCache keeps the most recently used graphs, so repeated SP requests of a saved
+// graph neither read and parse its vertex file nor compute its distances and MST
+// again.  The graphs are shared, the requests must not modify them.  The mutex
+// serializes the handler goroutines.  readGraphFile takes it while holding the read
+// lock of vertexFiles, it is never held while taking vertexFiles.
+type graphCache struct {
+	sync.Mutex
+	size    int                      // maximum graphs, the cache is off if 0
+	order   *list.List               // graphs from most to least recently used
+	entries map[string]*list.Element // graphs by graph ID
+}
+
+// the graph cache sized by the -graphcache flag
+var savedGraphs = &graphCache{size: defaultGraphCacheSize, order: list.New(), entries: make(map[string]*list.Element)}
+
+// graphID returns the ID of the graph block of the vertex file.  The file size and
+// modification time are part of it, so saving or editing the file changes the ID.
+func graphID(file string, block int) (string, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %d %d %d", file, fi.Size(), fi.ModTime().UnixNano(), block), nil
+}
+
+// get returns the graph and marks it most recently used
+func (gc *graphCache) get(id string) (*graphEntry, bool) {
+	gc.Lock()
+	defer gc.Unlock()
+	elem, ok := gc.entries[id]
+	if !ok {
+		return nil, false
+	}
+	gc.order.MoveToFront(elem)
+	return elem.Value.(*graphEntry), true
+}
+
+// put adds the parsed graph block, removing the least recently used graph when full
+func (gc *graphCache) put(id string, block *GraphBlock, blocks int) {
+	if gc.size == 0 {
+		return
+	}
+	gc.Lock()
+	defer gc.Unlock()
+	if _, ok := gc.entries[id]; ok {
+		return
+	}
+	gc.entries[id] = gc.order.PushFront(&graphEntry{id: id, block: block, blocks: blocks})
+	for gc.order.Len() > gc.size {
+		oldest := gc.order.Back()
+		gc.order.Remove(oldest)
+		delete(gc.entries, oldest.Value.(*graphEntry).id)
+	}
+}
+
+// mstKey returns the MST options of the request, or false if the MST cannot be
+// cached because the graph is not a saved graph in the graph cache.  Weights imported
+// with the graph count as options.
+func (p *PrimMST) mstKey(r *http.Request) (string, bool) {
+	if len(p.graphID) == 0 || savedGraphs.size == 0 {
+		return "", false
+	}
+	form := url.Values{"metric": {p.metric}, "importweights": {p.plot.EdgeWeights}, "importedgesonly": {p.plot.EdgesOnly}}
+	for _, name := range mstOptions {
+		form.Set(name, r.FormValue(name))
+	}
+	return form.Encode(), true
+}
+
+// cachedMST restores the distances and MST of the MST options from the graph cache,
+// it returns false if they are not cached
+func (p *PrimMST) cachedMST(key string) bool {
+	entry, ok := savedGraphs.get(p.graphID)
+	if !ok {
+		return false
+	}
+	savedGraphs.Lock()
+	defer savedGraphs.Unlock()
+	if entry.mstKey != key {
+		return false
+	}
+	p.graph, p.mst, p.start, p.neighbors, p.obstacles = entry.graph, entry.mst, entry.start, entry.neighbors, entry.obstacles
+	p.plot.Obstacles, p.plot.EdgeProb, p.plot.EdgeProbEdges = entry.plot.obstacles, entry.plot.edgeProb, entry.plot.edgeProbEdges
+	p.plot.EdgeWeights, p.plot.EdgesOnly = entry.plot.edgeWeights, entry.plot.edgesOnly
+	p.plot.KNN, p.plot.StartVert = entry.plot.knn, entry.plot.startVert
+	return true
+}
+
+// cacheMST keeps the distances and MST of the MST options in the graph cache,
+// replacing those of other options
+func (p *PrimMST) cacheMST(key string) {
+	entry, ok := savedGraphs.get(p.graphID)
+	if !ok {
+		return
+	}
+	savedGraphs.Lock()
+	defer savedGraphs.Unlock()
+	entry.mstKey = key
+	entry.graph, entry.mst, entry.start, entry.neighbors, entry.obstacles = p.graph, p.mst, p.start, p.neighbors, p.obstacles
+	entry.plot = mstPlot{
+		obstacles: p.plot.Obstacles, edgeProb: p.plot.EdgeProb, edgeProbEdges: p.plot.EdgeProbEdges,
+		edgeWeights: p.plot.EdgeWeights, edgesOnly: p.plot.EdgesOnly, knn: p.plot.KNN, startVert: p.plot.StartVert,
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// TestMSTCacheQuery asks /api/mst twice by GET for the MST of a saved graph from two
+// start vertices.  The start vertex is read from the query, so it is part of the MST
+// cache key and the second request is not answered with the cached MST of the first.
+func TestMSTCacheQuery(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-mstcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, start := range []int{1, 2} {
+		r := httptest.NewRequest(http.MethodGet, patternMST+"?slot=test-mstcache&seed=5&startvert="+strconv.Itoa(start), nil)
+		w := httptest.NewRecorder()
+		handleMST(w, r)
+		var mst MSTT
+		if err := json.Unmarshal(w.Body.Bytes(), &mst); err != nil {
+			t.Fatalf("start vertex %d: %v", start, err)
+		}
+		if w.Code != http.StatusOK || mst.Start != start {
+			t.Errorf("GET start vertex %d answered %d with start vertex %d", start, w.Code, mst.Start)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const defaultDBDriver = "sqlite" // database/sql driver of the graph database, registered by sqlite.go
+
+// graphDBSchema creates the tables of the graph database.  Each saved graph gets an
+// auto-assigned ID, its vertices are numbered from 0 in the order of the graph.
+var graphDBSchema = []string{
+	`CREATE TABLE IF NOT EXISTS graphs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		xmin REAL NOT NULL,
+		ymin REAL NOT NULL,
+		xmax REAL NOT NULL,
+		ymax REAL NOT NULL,
+		created TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS vertices (
+		graph INTEGER NOT NULL REFERENCES graphs(id),
+		vertex INTEGER NOT NULL,
+		x REAL NOT NULL,
+		y REAL NOT NULL,
+		attr REAL,
+		name TEXT,
+		PRIMARY KEY (graph, vertex)
+	)`,
+}
+
+// graphDB is the graph database set by the -db flag, nil if it is off or could not
+// be opened.  The vertex files are written and read as before without it.
+var graphDB *sql.DB
+
+// openGraphDB opens the graph database and creates its tables
+func openGraphDB(driver, source string) (*sql.DB, error) {
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, stmt := range graphDBSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// saveDBGraph inserts the endpoints and vertices into the graph database and sets the
+// graph database ID of the plot.  The vertex file is already saved, so a database
+// failure only leaves the graph without an ID.
+func (p *PrimMST) saveDBGraph() {
+	if graphDB == nil {
+		return
+	}
+	id, err := insertDBGraph(graphDB, p.Endpoints, p.location, p.attr, p.names)
+	if err != nil {
+		fmt.Printf("graph database save error: %v\n", err)
+		return
+	}
+	p.plot.DBGraph = strconv.FormatInt(id, 10)
+}
+
+// insertDBGraph inserts the graph in one transaction and returns its ID
+func insertDBGraph(db *sql.DB, ep *Endpoints, location []complex128, attr []float64, names []string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO graphs (xmin, ymin, xmax, ymax, created) VALUES (?, ?, ?, ?, ?)",
+		ep.xmin, ep.ymin, ep.xmax, ep.ymax, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare("INSERT INTO vertices (graph, vertex, x, y, attr, name) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for v, z := range location {
+		// The attributes and names are optional, NULL without them
+		var a sql.NullFloat64
+		var name sql.NullString
+		if v < len(attr) {
+			a = sql.NullFloat64{Float64: attr[v], Valid: true}
+		}
+		if v < len(names) {
+			name = sql.NullString{String: names[v], Valid: true}
+		}
+		if _, err := stmt.Exec(id, v, real(z), imag(z), a, name); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// readDBGraph reads the graph of the graph database ID.  The graphs in the database
+// are never changed, so the graph is kept in the graph cache by its ID.  It returns
+// nil without an ID, or after printing the error if the database is off or fails, so
+// the caller reads the vertex file of the slot instead.  An ID that is invalid or not
+// in the database is an error.
+func readDBGraph(dbgraph string) (*GraphBlock, error) {
+	if len(dbgraph) == 0 {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(dbgraph, 10, 64)
+	if err != nil || id < 1 {
+		return nil, fmt.Errorf("graph database ID %q must be a positive integer", dbgraph)
+	}
+	if graphDB == nil {
+		fmt.Printf("graph database is off, graph %d is read from the vertex file\n", id)
+		return nil, nil
+	}
+	key := "db " + dbgraph
+	if entry, ok := savedGraphs.get(key); ok {
+		return entry.block, nil
+	}
+	graph, err := selectDBGraph(graphDB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("graph %d is not in the graph database", id)
+	}
+	if err != nil {
+		fmt.Printf("graph database read error: %v, graph %d is read from the vertex file\n", err, id)
+		return nil, nil
+	}
+	graph.id = key
+	savedGraphs.put(key, graph, 1)
+	return graph, nil
+}
+
+// selectDBGraph reads the endpoints and vertices of the graph from the database
+func selectDBGraph(db *sql.DB, id int64) (*GraphBlock, error) {
+	ep := &Endpoints{}
+	if err := db.QueryRow("SELECT xmin, ymin, xmax, ymax FROM graphs WHERE id = ?", id).
+		Scan(&ep.xmin, &ep.ymin, &ep.xmax, &ep.ymax); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT vertex, x, y, attr, name FROM vertices WHERE graph = ? ORDER BY vertex", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := &GraphBlock{Endpoints: ep, location: make([]complex128, 0)}
+	attr, names := make([]float64, 0), make([]string, 0)
+	hasAttr, hasNames := false, false
+	for rows.Next() {
+		var v int
+		var x, y float64
+		var a sql.NullFloat64
+		var name sql.NullString
+		if err := rows.Scan(&v, &x, &y, &a, &name); err != nil {
+			return nil, err
+		}
+		if v != len(graph.location) {
+			return nil, fmt.Errorf("graph %d is missing vertex %d", id, len(graph.location))
+		}
+		graph.location = append(graph.location, complex(x, y))
+		attr = append(attr, a.Float64)
+		names = append(names, name.String)
+		hasAttr = hasAttr || a.Valid
+		hasNames = hasNames || name.Valid
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(graph.location) < 2 {
+		return nil, fmt.Errorf("graph %d has %d vertices, at least 2 are needed", id, len(graph.location))
+	}
+	if hasAttr {
+		graph.attr = attr
+	}
+	if hasNames {
+		graph.names = names
+	}
+	return graph, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	graphIDPrefix = "g-"           // slot prefix of the generated graph IDs
+	graphIDTTL    = 24 * time.Hour // age when the vertex file of a generated graph is removed
+)
+
+// newGraphID returns a random graph ID, the slot of a new graph generated without
+// one.  It is hard to guess so other users do not read or replace the graph.
+func newGraphID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := crand.Read(b); err != nil {
+		return "", fmt.Errorf("graph ID error: %v", err)
+	}
+	return graphIDPrefix + hex.EncodeToString(b), nil
+}
+
+// removeStaleGraphs removes the vertex files of the generated graphs last saved more
+// than graphIDTTL before now.  The named slots are kept.
+func removeStaleGraphs(now time.Time) {
+	files, err := filepath.Glob(slotPath(graphIDPrefix + "*"))
+	if err != nil {
+		fmt.Printf("Glob vertex files error: %v\n", err)
+		return
+	}
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil || now.Sub(fi.ModTime()) < graphIDTTL {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			fmt.Printf("Remove file %s error: %v\n", file, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GraphML document elements used by the import.  Node coordinates and edge weights
+// are data elements whose keys are declared with attr.name x, y and weight.
+type graphMLDoc struct {
+	Keys  []graphMLKey `xml:"key"`
+	Graph struct {
+		Nodes []graphMLNode `xml:"node"`
+		Edges []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// importGraphML reads the vertex locations from the GraphML node x and y data and the
+// vertex attributes from the optional node elevation data.  It returns the edge
+// weights as "v,w,weight" lines for applyWeights.  The vertices are
+// numbered in the order of the nodes in the file.  Edges without a weight keep
+// their Euclidean distance.
+func (p *PrimMST) importGraphML(r io.Reader) (string, error) {
+	var doc graphMLDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return "", fmt.Errorf("GraphML parse error: %v", err)
+	}
+
+	// Find the key ids of the x, y and weight data
+	var xKey, yKey, weightKey, elevationKey string
+	for _, k := range doc.Keys {
+		switch {
+		case k.Name == "x" && (k.For == "node" || k.For == "all"):
+			xKey = k.ID
+		case k.Name == "y" && (k.For == "node" || k.For == "all"):
+			yKey = k.ID
+		case k.Name == "weight" && (k.For == "edge" || k.For == "all"):
+			weightKey = k.ID
+		case k.Name == "elevation" && (k.For == "node" || k.For == "all"):
+			elevationKey = k.ID
+		}
+	}
+	if len(xKey) == 0 || len(yKey) == 0 {
+		return "", fmt.Errorf("GraphML has no node x and y keys")
+	}
+
+	nodes := len(doc.Graph.Nodes)
+	if nodes < 2 {
+		return "", fmt.Errorf("GraphML has %d nodes, at least 2 are needed", nodes)
+	}
+	vertex := make(map[string]int, nodes)
+	p.location = make([]complex128, nodes)
+	// The node ids name the vertices
+	p.names = make([]string, nodes)
+	p.attr = nil
+	if len(elevationKey) > 0 {
+		p.attr = make([]float64, nodes)
+	}
+	for i, n := range doc.Graph.Nodes {
+		if _, ok := vertex[n.ID]; ok {
+			return "", fmt.Errorf("GraphML node id %q is duplicated", n.ID)
+		}
+		vertex[n.ID] = i
+		p.names[i] = n.ID
+		var x, y float64
+		found := 0
+		for _, d := range n.Data {
+			// The optional node elevation is the vertex attribute, missing values are 0
+			if d.Key == elevationKey && len(elevationKey) > 0 {
+				elevation, err := strconv.ParseFloat(strings.TrimSpace(d.Value), 64)
+				if err != nil {
+					return "", fmt.Errorf("GraphML node %q elevation %q is not a number", n.ID, d.Value)
+				}
+				p.attr[i] = elevation
+				continue
+			}
+			if d.Key != xKey && d.Key != yKey {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(d.Value), 64)
+			if err != nil {
+				return "", fmt.Errorf("GraphML node %q coordinate %q is not a number", n.ID, d.Value)
+			}
+			if d.Key == xKey {
+				x = value
+			} else {
+				y = value
+			}
+			found++
+		}
+		if found != 2 {
+			return "", fmt.Errorf("GraphML node %q needs x and y data", n.ID)
+		}
+		p.location[i] = complex(x, y)
+	}
+	p.Endpoints = fitEndpoints(p.location)
+
+	weights := make([]string, 0)
+	for _, e := range doc.Graph.Edges {
+		v, ok := vertex[e.Source]
+		if !ok {
+			return "", fmt.Errorf("GraphML edge source %q is not a node", e.Source)
+		}
+		w, ok := vertex[e.Target]
+		if !ok {
+			return "", fmt.Errorf("GraphML edge target %q is not a node", e.Target)
+		}
+		if v == w {
+			return "", fmt.Errorf("GraphML edge %q-%q is a loop", e.Source, e.Target)
+		}
+		for _, d := range e.Data {
+			if d.Key != weightKey || len(weightKey) == 0 {
+				continue
+			}
+			weight, err := strconv.ParseFloat(strings.TrimSpace(d.Value), 64)
+			if err != nil || weight < 0 {
+				return "", fmt.Errorf("GraphML edge %q-%q weight %q must be a number >= 0", e.Source, e.Target, d.Value)
+			}
+			weights = append(weights, fmt.Sprintf("%d,%d,%g", v, w, weight))
+		}
+	}
+
+	return strings.Join(weights, "\n"), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// legacySlotPrefix starts the vertex file name of a named graph slot saved before the
+// slots moved to the graphs directory
+const legacySlotPrefix = "vertices_"
+
+// Type to contain a named graph slot listed by /graphs
+type GraphSlotT struct {
+	Slot     string `json:"slot"`
+	Vertices int    `json:"vertices"` // vertices of graph block 0
+	Blocks   int    `json:"blocks"`   // graph blocks in the vertex file
+	Modified string `json:"modified"` // time the graph was last saved, RFC 3339
+	Load     string `json:"load"`     // URL showing the graph, ready for SP queries
+}
+
+// Type to contain the named graph slots returned by /graphs
+type GraphListT struct {
+	Graphs []GraphSlotT `json:"graphs"`
+}
+
+// migrateSlotFiles moves the vertex files of the named graph slots saved before the
+// graphs directory into it.  A slot already in the graphs directory is not replaced.
+func migrateSlotFiles() error {
+	files, err := filepath.Glob(legacySlotPrefix + "*.csv")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		slot := strings.TrimSuffix(strings.TrimPrefix(file, legacySlotPrefix), ".csv")
+		to, err := slotFile(slot)
+		if err != nil {
+			// not a slot, such as a temporary file
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			fmt.Printf("graph slot %s is in %s, %s is not moved\n", slot, to, file)
+			continue
+		}
+		if err := os.MkdirAll(dirGraphs, 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(file, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listGraphs returns the named graph slots in the graphs directory sorted by name.
+// The generated graph IDs are private to the users who made them and not listed.
+func listGraphs() ([]GraphSlotT, error) {
+	files, err := filepath.Glob(slotPath("*"))
+	if err != nil {
+		return nil, err
+	}
+	graphs := make([]GraphSlotT, 0, len(files))
+	for _, file := range files {
+		slot := strings.TrimSuffix(filepath.Base(file), ".csv")
+		if strings.HasPrefix(slot, graphIDPrefix) || !slotPattern.MatchString(slot) {
+			continue
+		}
+		fi, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		blocks, err := readSlotGraphs(file)
+		if err != nil {
+			fmt.Printf("graph slot %s error: %v\n", slot, err)
+			continue
+		}
+		graphs = append(graphs, GraphSlotT{
+			Slot:     slot,
+			Vertices: len(blocks[0].location),
+			Blocks:   len(blocks),
+			Modified: fi.ModTime().UTC().Format(time.RFC3339),
+			Load:     patternDijkstraSP + "?" + url.Values{"slot": {slot}, "load": {"1"}}.Encode(),
+		})
+	}
+	sort.Slice(graphs, func(i, j int) bool { return graphs[i].Slot < graphs[j].Slot })
+	return graphs, nil
+}
+
+// readSlotGraphs reads the graph blocks of the vertex file without adding them to the
+// graph cache, so listing the slots does not evict the graphs in use
+func readSlotGraphs(file string) ([]*GraphBlock, error) {
+	vertexFiles.RLock()
+	defer vertexFiles.RUnlock()
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readGraphs(f)
+}
+
+// HTTP handler for /graphs connections.  It lists the named graph slots, each with the
+// URL that loads it for new SP queries.
+func handleGraphs(w http.ResponseWriter, r *http.Request) {
+	graphs, err := listGraphs()
+	if err != nil {
+		writeAPIError(w, apiInternal, err.Error())
+		return
+	}
+	writeJSON(w, GraphListT{Graphs: graphs})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestGraphSlots saves a named graph slot and a generated graph and checks that only
+// the named slot is listed, that it loads for SP queries and that slot names leaving
+// the graphs directory are rejected
+func TestGraphSlots(t *testing.T) {
+	tempGraphs(t)
+	for _, slot := range []string{"..", "../vertices", "a/b", `a\b`, ".hidden"} {
+		if _, err := slotFile(slot); err == nil {
+			t.Fatalf("graph slot %q is not rejected", slot)
+		}
+	}
+
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}
+	save := func(slot string) error {
+		file, err := slotFile(slot)
+		if err != nil {
+			return err
+		}
+		p := &PrimMST{plot: &PlotT{}, location: location, Endpoints: &bounds, file: file}
+		return p.saveVertices()
+	}
+	if err := save("test-slot"); err != nil {
+		t.Fatal(err)
+	}
+	id, err := newGraphID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := save(id); err != nil {
+		t.Fatal(err)
+	}
+
+	graphs, err := listGraphs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := false
+	for _, g := range graphs {
+		if g.Slot == id {
+			t.Fatalf("generated graph %s is listed", id)
+		}
+		if g.Slot == "test-slot" {
+			listed = g.Vertices == len(location) && g.Blocks == 1
+		}
+	}
+	if !listed {
+		t.Fatalf("graph slot test-slot is not listed with %d vertices: %+v", len(location), graphs)
+	}
+
+	p := &PrimMST{plot: &PlotT{}}
+	if err := p.generateVertices(postForm(url.Values{"slot": {"test-slot"}, "load": {"1"}})); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.location) != len(location) || p.plot.Slot != "test-slot" {
+		t.Fatalf("loaded graph slot %q has %d vertices, want test-slot with %d", p.plot.Slot, len(p.location), len(location))
+	}
+}
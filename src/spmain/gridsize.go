@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Grid resolution in rows and columns, the grid is square.  Every grid cell is
+// cellPixels wide in the page, so the largest grid makes a page of a million cells.
+const (
+	defaultGridSize = 300  // #rows and #columns in the grid if the form has no grid size
+	minGridSize     = 100  // smallest grid size
+	maxGridSize     = 1000 // largest grid size
+	maxDrawingSize  = 2000 // largest drawing grid size, the grid size times supersample
+	cellPixels      = 2    // width and height of a grid cell in the page
+)
+
+// size returns the number of rows and columns of the display grid
+func (plot *PlotT) size() int {
+	if plot.gridSize == 0 {
+		return defaultGridSize
+	}
+	return plot.gridSize
+}
+
+// parseGridSize gets the grid size from the HTML form or the URL, the default grid
+// size if it is empty.  The drawing grid is limited to maxDrawingSize, a larger
+// supersample of the grid size is an error.
+func (plot *PlotT) parseGridSize(r *http.Request) error {
+	plot.GridSize = strings.TrimSpace(r.FormValue("gridsize"))
+	plot.gridSize = defaultGridSize
+	if len(plot.GridSize) == 0 {
+		plot.GridSize = strconv.Itoa(defaultGridSize)
+		return nil
+	}
+	size, err := strconv.Atoi(plot.GridSize)
+	if err != nil || size < minGridSize || size > maxGridSize {
+		plot.GridSize = strconv.Itoa(defaultGridSize)
+		return fmt.Errorf("grid size must be %d-%d", minGridSize, maxGridSize)
+	}
+	if size*plot.supersample > maxDrawingSize {
+		plot.GridSize = strconv.Itoa(defaultGridSize)
+		return fmt.Errorf("grid size %d with supersample %d exceeds the drawing grid size %d", size, plot.supersample, maxDrawingSize)
+	}
+	plot.gridSize = size
+	return nil
+}
+
+// GridRows returns the number of rows and columns of the grid in the page
+func (plot *PlotT) GridRows() int {
+	return plot.size()
+}
+
+// GridPixels returns the width and height of the grid in the page
+func (plot *PlotT) GridPixels() int {
+	return plot.size() * cellPixels
+}
+
+// LabelPixels returns the spacing of the axis labels in the page, the labels
+// divide the grid into equal parts
+func (plot *PlotT) LabelPixels() int {
+	return plot.GridPixels() / (xlabels - 1)
+}
+
+// YTicks returns the grid cells, counted from 1 as by CSS nth-child, whose bottom
+// border is a y-axis tick.  They are the first cells of the rows at the labels.
+func (plot *PlotT) YTicks() []int {
+	n := plot.size()
+	ticks := make([]int, 0, ylabels-2)
+	for k := 1; k < ylabels-1; k++ {
+		ticks = append(ticks, k*n/(ylabels-1)*n+1)
+	}
+	return ticks
+}
+
+// XTicks returns the grid cells, counted from 1 as by CSS nth-child, whose left
+// border is an x-axis tick.  They are the cells of the bottom row at the labels.
+func (plot *PlotT) XTicks() []int {
+	n := plot.size()
+	ticks := make([]int, 0, xlabels-2)
+	for k := 1; k < xlabels-1; k++ {
+		ticks = append(ticks, (n-1)*n+k*n/(xlabels-1))
+	}
+	return ticks
+}
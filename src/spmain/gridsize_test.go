@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestGridSizes plots a small graph at the smallest, an uneven and the largest grid
+// size and checks the grid cells, the corner vertices and the axis ticks of the page.
+// Grid sizes outside the range or too large for the supersample are rejected.
+func TestGridSizes(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(0, 0), complex(10, 10), complex(3, 7), complex(10, 0)}
+	for _, size := range []int{minGridSize, 150, maxGridSize} {
+		plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+		if err := plot.parseGridSize(postForm(url.Values{"gridsize": {strconv.Itoa(size)}})); err != nil {
+			t.Fatal(err)
+		}
+		primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotGrid(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotMST(nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(plot.Grid) != size*size {
+			t.Fatalf("grid size %d has %d cells, want %d", size, len(plot.Grid), size*size)
+		}
+		// Bottom left, top right and bottom right vertices
+		for _, cell := range []int{(size - 1) * size, size - 1, size*size - 1} {
+			if len(plot.Grid[cell]) == 0 {
+				t.Fatalf("grid size %d corner cell %d is not drawn", size, cell)
+			}
+		}
+		if len(plot.Xlabel) != xlabels || len(plot.Ylabel) != ylabels {
+			t.Fatalf("grid size %d has %d x labels and %d y labels", size, len(plot.Xlabel), len(plot.Ylabel))
+		}
+		var page bytes.Buffer
+		if err := tmplForm.Execute(&page, plot); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(page.String(), fmt.Sprintf("repeat(%d, 2px)", size)) {
+			t.Fatalf("grid size %d page has no %d grid columns", size, size)
+		}
+		for _, tick := range append(plot.XTicks(), plot.YTicks()...) {
+			if tick < 1 || tick > size*size {
+				t.Fatalf("grid size %d tick cell %d is outside the grid", size, tick)
+			}
+		}
+	}
+
+	for _, form := range []url.Values{{"gridsize": {"99"}}, {"gridsize": {"1001"}}, {"gridsize": {"big"}},
+		{"gridsize": {"1000"}, "supersample": {"4"}}} {
+		plot := &PlotT{supersample: 1}
+		if form.Get("supersample") == "4" {
+			plot.supersample = 4
+		}
+		if err := plot.parseGridSize(postForm(form)); err == nil {
+			t.Fatalf("%s is not rejected", form.Encode())
+		}
+		if plot.size() != defaultGridSize {
+			t.Fatalf("%s falls back to grid size %d, want %d", form.Encode(), plot.size(), defaultGridSize)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"net/http"
+)
+
+// Type to contain the /healthz JSON response
+type HealthT struct {
+	Status string `json:"status"` // ok while the server is serving
+}
+
+// HTTP handler for /healthz connections.  It answers the liveness and readiness
+// probes of a load balancer with 200 and {"status":"ok"}, without reading the vertex
+// files or building a graph.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, HealthT{Status: "ok"})
+}
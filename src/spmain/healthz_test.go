@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHealthz checks that the health check answers 200 with status ok as JSON
+func TestHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleHealthz(w, httptest.NewRequest(http.MethodGet, patternHealthz, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "{\"status\":\"ok\"}\n" || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("health check answered %d %q", w.Code, w.Body.String())
+	}
+}
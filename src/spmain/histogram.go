@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+const defaultBins = 10 // number of edge length histogram bins when none is given
+
+// Type to contain one edge length histogram bin, lengths in [Low, High)
+type BinT struct {
+	Low   float64 `json:"low"`   // smallest edge length in the bin
+	High  float64 `json:"high"`  // bin upper limit, the last bin includes it
+	Count int     `json:"count"` // number of edges in the bin
+}
+
+// Type to contain the edge length histogram JSON response
+type HistogramT struct {
+	Edges  string  `json:"edges"`  // edge set, mst or graph
+	Count  int     `json:"count"`  // number of edges
+	Min    float64 `json:"min"`    // shortest edge length
+	Max    float64 `json:"max"`    // longest edge length
+	Mean   float64 `json:"mean"`   // average edge length
+	Median float64 `json:"median"` // median edge length
+	Bins   []BinT  `json:"bins"`   // equal width bins from min to max
+}
+
+// edgeLengths returns the lengths of the MST edges, or of all the graph edges if
+// mstOnly is false.  Custom edge weights are used in place of the Euclidean distance
+// and missing edges, which have infinite length, are left out.
+func (p *PrimMST) edgeLengths(mstOnly bool) []float64 {
+	lengths := make([]float64, 0)
+	if mstOnly {
+		for _, e := range p.mst.edges() {
+			lengths = append(lengths, p.graph[e.v][e.w])
+		}
+		return lengths
+	}
+	for v := range p.graph {
+		for w := v + 1; w < len(p.graph); w++ {
+			if p.graph[v][w] == infinity {
+				continue
+			}
+			lengths = append(lengths, p.graph[v][w])
+		}
+	}
+	return lengths
+}
+
+// histogram bins the lengths into equal width bins and finds the summary statistics
+func histogram(lengths []float64, bins int) HistogramT {
+	h := HistogramT{Count: len(lengths), Bins: make([]BinT, bins)}
+	if len(lengths) == 0 {
+		return h
+	}
+
+	sorted := make([]float64, len(lengths))
+	copy(sorted, lengths)
+	sort.Float64s(sorted)
+	h.Min, h.Max = sorted[0], sorted[len(sorted)-1]
+	n := len(sorted)
+	if n%2 == 1 {
+		h.Median = sorted[n/2]
+	} else {
+		h.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	for _, length := range sorted {
+		h.Mean += length
+	}
+	h.Mean /= float64(n)
+
+	width := (h.Max - h.Min) / float64(bins)
+	for i := range h.Bins {
+		h.Bins[i].Low = h.Min + float64(i)*width
+		h.Bins[i].High = h.Min + float64(i+1)*width
+	}
+	for _, length := range sorted {
+		i := bins - 1
+		if width > 0 {
+			i = int((length - h.Min) / width)
+		}
+		// The maximum length belongs in the last bin
+		if i > bins-1 {
+			i = bins - 1
+		}
+		h.Bins[i].Count++
+	}
+
+	return h
+}
+
+// HTTP handler for /api/histogram connections.  It returns the edge length histogram
+// of the MST (edges=mst, the default) or the complete graph (edges=graph).
+func handleHistogram(w http.ResponseWriter, r *http.Request) {
+	bins, err := formInt(r, "bins", defaultBins)
+	if err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if bins < 1 || bins > defaultGridSize {
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("bins must be 1-%d", defaultGridSize))
+		return
+	}
+	edges := r.FormValue("edges")
+	switch edges {
+	case "":
+		edges = "mst"
+	case "mst", "graph":
+	default:
+		writeAPIError(w, apiInvalidInput, fmt.Sprintf("edges %q must be mst or graph", edges))
+		return
+	}
+
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	h := histogram(primmst.edgeLengths(edges == "mst"), bins)
+	h.Edges = edges
+	writeJSON(w, h)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// convexHull finds the vertices on the convex hull of the Euclidean graph using
+// Andrew's monotone chain algorithm.  The vertex indices are returned in
+// counter-clockwise order.
+func (p *PrimMST) convexHull() []int {
+	n := len(p.location)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if n < 3 {
+		return order
+	}
+
+	// Sort the vertices by x, then y
+	sort.Slice(order, func(i, j int) bool {
+		a, b := p.location[order[i]], p.location[order[j]]
+		if real(a) != real(b) {
+			return real(a) < real(b)
+		}
+		return imag(a) < imag(b)
+	})
+
+	// cross is the z component of the cross product (a-o) x (b-o)
+	cross := func(o, a, b int) float64 {
+		oa := p.location[a] - p.location[o]
+		ob := p.location[b] - p.location[o]
+		return real(oa)*imag(ob) - imag(oa)*real(ob)
+	}
+
+	hull := make([]int, 0, 2*n)
+	// Build the lower hull
+	for _, v := range order {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], v) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, v)
+	}
+	// Build the upper hull
+	lower := len(hull) + 1
+	for i := n - 2; i >= 0; i-- {
+		v := order[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], v) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, v)
+	}
+
+	// The last vertex is the same as the first one
+	return hull[:len(hull)-1]
+}
+
+// shortestFrom finds the distance and previous vertex on the shortest path from
+// src to every vertex in the adjacency list.  The adjacency edges are not modified.
+func (dsp *DijksraSP) shortestFrom(src int) ([]float64, []int) {
+	vertices := len(dsp.adj)
+	distTo := make([]float64, vertices)
+	prev := make([]int, vertices)
+	for i := range distTo {
+		distTo[i] = infinity
+		prev[i] = -1
+	}
+
+	// Items are inserted again instead of updated, stale items are skipped
+	pq := newPriorityQueue()
+	distTo[src] = 0.0
+	heap.Push(&pq, &Item{Edge: Edge{v: src, w: src}, distance: 0.0})
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(*Item)
+		v := item.w
+		if item.distance > distTo[v] {
+			continue
+		}
+		for _, e := range dsp.adj[v] {
+			w := e.w
+			if w == v {
+				w = e.v
+			}
+			newDistance := distTo[v] + dsp.graph[v][w]
+			if lessDistance(newDistance, distTo[w]) {
+				distTo[w] = newDistance
+				prev[w] = v
+				heap.Push(&pq, &Item{Edge: Edge{v: v, w: w}, distance: newDistance})
+			}
+		}
+	}
+
+	return distTo, prev
+}
+
+// findSPViaHull finds the shortest path from source to target that passes through
+// at least one convex hull vertex, draws it in the grid and reports the chosen
+// hull vertex and the extra distance compared to the unconstrained shortest path.
+func (dsp *DijksraSP) findSPViaHull(hull []int) error {
+	if len(dsp.distTo) == 0 || dsp.distTo[dsp.target] == infinity {
+		return fmt.Errorf("distance to vertex %d not found", dsp.target)
+	}
+
+	distSource, prevSource := dsp.shortestFrom(dsp.source)
+	distTarget, prevTarget := dsp.shortestFrom(dsp.target)
+
+	// Pick the hull vertex that minimizes source->hull->target
+	best := -1
+	bestDistance := infinity
+	for _, h := range hull {
+		if distSource[h] == infinity || distTarget[h] == infinity {
+			continue
+		}
+		if d := distSource[h] + distTarget[h]; lessDistance(d, bestDistance) {
+			best = h
+			bestDistance = d
+		}
+	}
+	if best < 0 {
+		return fmt.Errorf("no convex hull vertex reachable from source and target")
+	}
+
+	// Construct the path source->best by walking back from best and reversing
+	path := make([]int, 0)
+	for v := best; v != -1; v = prevSource[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	// Append the path best->target, the target tree parents point toward target
+	for v := prevTarget[best]; v != -1; v = prevTarget[v] {
+		path = append(path, v)
+	}
+
+	dsp.plotPath(path, "edgeHull")
+
+	// Mark the hull vertex Purple and restore the SP source Blue and target Red
+	dsp.plot.setMarker(dsp.location[best], "vertexHull")
+	dsp.plot.setMarker(dsp.location[dsp.source], "vertexSP1")
+	dsp.plot.setMarker(dsp.location[dsp.target], "vertexSP2")
+
+	dsp.plot.HullVertex = strconv.Itoa(best)
+	dsp.plot.HullLocation = dsp.plot.withUnits(fmt.Sprintf("(%.2f, %.2f)", real(dsp.location[best]), imag(dsp.location[best])))
+	dsp.plot.HullDistance = dsp.plot.withUnits(fmt.Sprintf("%.2f", bestDistance))
+	dsp.plot.HullExtra = dsp.plot.withUnits(fmt.Sprintf("%.2f", bestDistance-dsp.distTo[dsp.target]))
+
+	return nil
+}
+
+// plotPath draws the edges connecting the path vertices in the grid
+func (dsp *DijksraSP) plotPath(path []int, class string) {
+	for i := 1; i < len(path); i++ {
+		dsp.plot.drawEdge(dsp.location[path[i-1]], dsp.location[path[i]], class)
+	}
+
+	// Mark the path vertices.  CSS colors the vertex Black.
+	for _, v := range path {
+		dsp.plot.setCell(real(dsp.location[v]), imag(dsp.location[v]), "vertex")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"math/cmplx"
+	"testing"
+)
+
+// TestHull finds the convex hull of a square of four corners around a vertex on its
+// bottom side and two inside it, then routes the SP between the inner vertices
+// through the hull.  The hull is the four corners in counter-clockwise order, and the
+// route goes through the corner closest to both inner vertices.
+func TestHull(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(5, 5), complex(1, 1), complex(9, 1), complex(9, 9), complex(1, 9), complex(5, 1), complex(3, 4)}
+	primmst := &PrimMST{plot: &PlotT{Units: "km", view: &bounds}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	hull := primmst.convexHull()
+	if fmt.Sprint(hull) != "[1 2 3 4]" {
+		t.Fatalf("convex hull %v, want [1 2 3 4]", hull)
+	}
+
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotGrid(); err != nil {
+		t.Fatal(err)
+	}
+	dsp := newDijkstraSP(primmst)
+	dsp.source, dsp.target, dsp.fullGraph = 0, 6, true
+	dsp.searchSP()
+	if err := dsp.findSPViaHull(hull); err != nil {
+		t.Fatal(err)
+	}
+	via := cmplx.Abs(location[1]-location[0]) + cmplx.Abs(location[6]-location[1])
+	extra := via - cmplx.Abs(location[6]-location[0])
+	if dsp.plot.HullVertex != "1" || dsp.plot.HullExtra != fmt.Sprintf("%.2f km", extra) {
+		t.Fatalf("SP through hull vertex %s with extra distance %s, want vertex 1 with %.2f km",
+			dsp.plot.HullVertex, dsp.plot.HullExtra, extra)
+	}
+}
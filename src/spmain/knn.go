@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// nearestNeighbors joins each vertex to its k nearest vertices by edge weight and
+// returns the sorted neighbors of every vertex.  The kNN relation is not symmetric,
+// so v and w are neighbors if either is near the other.
+func (p *PrimMST) nearestNeighbors(k int) ([][]int, error) {
+	vertices := len(p.location)
+	if k < 1 || k > vertices-1 {
+		return nil, fmt.Errorf("k %d must be 1-%d", k, vertices-1)
+	}
+	joined := make([]map[int]bool, vertices)
+	for v := range joined {
+		joined[v] = make(map[int]bool)
+	}
+	nearest := make([]int, 0, vertices-1)
+	for v := range p.location {
+		nearest = nearest[:0]
+		for w := range p.location {
+			if w != v && p.graph[v][w] < infinity {
+				nearest = append(nearest, w)
+			}
+		}
+		sort.Slice(nearest, func(i, j int) bool { return p.graph[v][nearest[i]] < p.graph[v][nearest[j]] })
+		if len(nearest) > k {
+			nearest = nearest[:k]
+		}
+		for _, w := range nearest {
+			joined[v][w] = true
+			joined[w][v] = true
+		}
+	}
+
+	neighbors := make([][]int, vertices)
+	for v := range joined {
+		neighbors[v] = make([]int, 0, len(joined[v]))
+		for w := range joined[v] {
+			neighbors[v] = append(neighbors[v], w)
+		}
+		sort.Ints(neighbors[v])
+	}
+	return neighbors, nil
+}
+
+// applyKNN keeps only the edges of the k-nearest-neighbor graph when the HTML form
+// has knn.  The neighbors are kept as adjacency lists for findMST and the full graph
+// SP search, and the other weights become infinity.  findMST reports a kNN graph
+// that falls apart.
+func (p *PrimMST) applyKNN(r *http.Request) error {
+	str := r.PostFormValue("knn")
+	if len(str) == 0 {
+		return nil
+	}
+	p.plot.KNN = str
+	k, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return err
+	}
+	neighbors, err := p.nearestNeighbors(k)
+	if err != nil {
+		return err
+	}
+
+	for v := range p.graph {
+		kept := make([]float64, len(neighbors[v]))
+		for i, w := range neighbors[v] {
+			kept[i] = p.graph[v][w]
+		}
+		for w := range p.graph[v] {
+			p.graph[v][w] = infinity
+		}
+		for i, w := range neighbors[v] {
+			p.graph[v][w] = kept[i]
+		}
+	}
+	p.neighbors = neighbors
+	return nil
+}
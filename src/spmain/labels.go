@@ -0,0 +1,48 @@
+package main
+
+import "strconv"
+
+// Placement of the vertex index labels in grid cells, a label sits above and to the
+// right of its vertex
+const (
+	labelOffset      = 2  // columns between the vertex and its label
+	labelRowCells    = 5  // rows of a label, its line height
+	labelCharCells   = 3  // columns of a label character
+	gridMarginPixels = 10 // left margin of the grid in the page
+	gridBorderPixels = 2  // border of the grid in the page
+)
+
+// Type to contain a vertex index label positioned over the display grid
+type LabelT struct {
+	Row   int    // display grid row of the top of the label
+	Col   int    // display grid column of the left of the label
+	Label string // vertex index
+}
+
+// Top returns the position of the label from the top of the grid container
+func (l LabelT) Top() int {
+	return gridBorderPixels + l.Row*cellPixels
+}
+
+// Left returns the position of the label from the left of the grid container
+func (l LabelT) Left() int {
+	return gridMarginPixels + gridBorderPixels + l.Col*cellPixels
+}
+
+// plotVertexLabels labels the shown vertices in the plotted region with their
+// index.  A label that would fall off the top or the right of the grid is skipped.
+func (p *PrimMST) plotVertexLabels() {
+	n := p.plot.size()
+	p.plot.VertexLabels = make([]LabelT, 0, len(p.location))
+	for v, z := range p.location {
+		if !p.isShown(v) || !p.plot.view.contains(real(z), imag(z)) {
+			continue
+		}
+		row, col := p.plot.view.toGridSize(real(z), imag(z), n, n)
+		label := LabelT{Row: row - labelRowCells, Col: col + labelOffset, Label: strconv.Itoa(v)}
+		if label.Row < 0 || label.Col+len(label.Label)*labelCharCells > n {
+			continue
+		}
+		p.plot.VertexLabels = append(p.plot.VertexLabels, label)
+	}
+}
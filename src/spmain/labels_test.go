@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestVertexLabels labels the vertices of a small graph and checks that a label is
+// offset above and to the right of its vertex and that the labels falling off the top
+// or the right of the grid are skipped
+func TestVertexLabels(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(5, 5), complex(10, 5), complex(5, 10), complex(0, 0)}
+	plot := &PlotT{view: &bounds, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, Endpoints: &bounds}
+	primmst.plotVertexLabels()
+	if len(plot.VertexLabels) != 2 {
+		t.Fatalf("%d vertex labels, want 2 without the right and top edge vertices", len(plot.VertexLabels))
+	}
+	row, col := bounds.toGridSize(5, 5, defaultGridSize, defaultGridSize)
+	if label := plot.VertexLabels[0]; label.Label != "0" || label.Row != row-labelRowCells || label.Col != col+labelOffset {
+		t.Fatalf("vertex 0 at row %d col %d has label %+v", row, col, label)
+	}
+	if label := plot.VertexLabels[1]; label.Label != "3" {
+		t.Fatalf("second label is %q, want 3", label.Label)
+	}
+}
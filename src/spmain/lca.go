@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// treeParents roots the MST at the start vertex and returns the parent, depth and
+// distance from the root of every vertex.  The root has parent -1.
+func (dsp *DijksraSP) treeParents() ([]int, []int, []float64) {
+	vertices := len(dsp.location)
+	tree := make([][]int, vertices)
+	for _, e := range dsp.mst.edges() {
+		tree[e.v] = append(tree[e.v], e.w)
+		tree[e.w] = append(tree[e.w], e.v)
+	}
+
+	parent := make([]int, vertices)
+	depth := make([]int, vertices)
+	distance := make([]float64, vertices)
+	for i := range parent {
+		parent[i] = -1
+	}
+	// Breadth-first traversal from the root
+	visited := make([]bool, vertices)
+	visited[dsp.start] = true
+	queue := []int{dsp.start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range tree[v] {
+			if visited[w] {
+				continue
+			}
+			visited[w] = true
+			parent[w] = v
+			depth[w] = depth[v] + 1
+			distance[w] = distance[v] + dsp.graph[v][w]
+			queue = append(queue, w)
+		}
+	}
+
+	return parent, depth, distance
+}
+
+// findLCA finds the lowest common ancestor of the source and target in the MST
+// rooted at the start vertex and reports the distance from each to it.  The MST
+// path between source and target always passes through the LCA.
+func (dsp *DijksraSP) findLCA() error {
+	parent, depth, distance := dsp.treeParents()
+
+	// Climb from the deeper vertex until both are at the same depth, then climb together
+	u, v := dsp.source, dsp.target
+	for depth[u] > depth[v] {
+		u = parent[u]
+	}
+	for depth[v] > depth[u] {
+		v = parent[v]
+	}
+	for u != v {
+		if parent[u] < 0 || parent[v] < 0 {
+			return fmt.Errorf("vertices %d and %d are not in the same tree", dsp.source, dsp.target)
+		}
+		u, v = parent[u], parent[v]
+	}
+	lca := u
+
+	dsp.plot.LCA = strconv.Itoa(lca)
+	dsp.plot.LCASourceDistance = dsp.plot.withUnits(fmt.Sprintf("%.2f", distance[dsp.source]-distance[lca]))
+	dsp.plot.LCATargetDistance = dsp.plot.withUnits(fmt.Sprintf("%.2f", distance[dsp.target]-distance[lca]))
+	switch lca {
+	case dsp.source, dsp.target:
+		dsp.plot.LCANote = fmt.Sprintf("vertex %d is an MST ancestor of the other vertex, both are on one branch", lca)
+	case dsp.start:
+		dsp.plot.LCANote = "source and target are in different MST branches, the path passes through the start vertex"
+	default:
+		dsp.plot.LCANote = fmt.Sprintf("source and target share the MST branch down to vertex %d", lca)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+// Type to contain one legend entry mapping a grid class to its meaning
+type LegendT struct {
+	Class string // CSS class of the grid cells
+	Label string // meaning of the class
+	Shape string // icon shape of the class in SVG output
+}
+
+// legend lists the grid classes in the order they are shown in the legend.  The
+// template colors each entry with its class, so the legend matches the grid CSS.
+var legend = []LegendT{
+	{Class: "startvertexMSS", Label: "MST start vertex", Shape: "diamond"},
+	{Class: "vertexSP1", Label: "SP source", Shape: "triangle"},
+	{Class: "vertexSP2", Label: "SP target", Shape: "square"},
+	{Class: "vertex", Label: "vertex", Shape: "circle"},
+	{Class: "edge", Label: "MST edge", Shape: "line"},
+	{Class: "edgeGraph", Label: "graph edge", Shape: "line"},
+	{Class: "edgeSP", Label: "shortest path", Shape: "line"},
+	{Class: "edgeSP2", Label: "2nd shortest path", Shape: "line"},
+	{Class: "edgeSP3", Label: "3rd shortest path", Shape: "line"},
+	{Class: "edgeSP4", Label: "4th shortest path", Shape: "line"},
+	{Class: "edgeSP5", Label: "5th shortest path", Shape: "line"},
+	{Class: "edgeSP6", Label: "6th shortest path", Shape: "line"},
+	{Class: "leaf", Label: "MST leaf", Shape: "circle"},
+	{Class: "vertexMoved", Label: "perturbed vertex", Shape: "circle"},
+	{Class: "vertexUnreachable", Label: "unreachable vertex", Shape: "circle"},
+	{Class: "vertexThrough", Label: "through vertex", Shape: "diamond"},
+	{Class: "clip", Label: "clip rectangle", Shape: "line"},
+	{Class: "exclusion", Label: "exclusion zone", Shape: "line"},
+	{Class: "obstacle", Label: "obstacle", Shape: "square"},
+	{Class: "edgeHull", Label: "SP via convex hull", Shape: "line"},
+	{Class: "vertexHull", Label: "convex hull vertex", Shape: "circle"},
+	{Class: "edgeTurn", Label: "turn limited SP", Shape: "line"},
+	{Class: "edgeSteiner", Label: "Steiner tree", Shape: "line"},
+	{Class: "terminal", Label: "Steiner terminal", Shape: "circle"},
+	{Class: "edgeCritical", Label: "critical link", Shape: "line"},
+	{Class: "edgeBackup", Label: "backup route", Shape: "line"},
+	{Class: "edgeDemo0", Label: "demo pair 1", Shape: "line"},
+	{Class: "edgeDemo1", Label: "demo pair 2", Shape: "line"},
+	{Class: "edgeDemo2", Label: "demo pair 3", Shape: "line"},
+	{Class: "edgeDemo3", Label: "demo pair 4", Shape: "line"},
+	{Class: "edgeDemo4", Label: "demo pair 5", Shape: "line"},
+	{Class: "edgeDemo5", Label: "demo pair 6", Shape: "line"},
+}
+
+// plotLegend sets the legend to the entries whose class is drawn in the grid
+func (plot *PlotT) plotLegend() {
+	drawn := make(map[string]bool)
+	for _, class := range plot.Grid {
+		drawn[class] = true
+	}
+	plot.Legend = make([]LegendT, 0, len(legend))
+	for _, entry := range legend {
+		if drawn[entry.Class] {
+			plot.Legend = append(plot.Legend, entry)
+		}
+	}
+}
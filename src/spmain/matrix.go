@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// importMatrix reads a weighted graph from an uploaded CSV adjacency matrix.  Row v
+// column w is the weight of the edge between v and w, an empty, zero or "inf" entry
+// is no edge and the diagonal is ignored.  The matrix must be square, symmetric and
+// without negative entries.  The vertices are placed on a circle only to plot them,
+// the weights do not depend on the locations.  It returns the edges as "v,w,weight"
+// lines for applyWeights.
+func (p *PrimMST) importMatrix(r io.Reader) (string, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+	rows, err := in.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("adjacency matrix CSV error: %v", err)
+	}
+	verts := len(rows)
+	if verts < 2 {
+		return "", fmt.Errorf("adjacency matrix has %d rows, at least 2 are needed", verts)
+	}
+
+	matrix := make([][]float64, verts)
+	for v, row := range rows {
+		if len(row) != verts {
+			return "", fmt.Errorf("adjacency matrix is not square, row %d has %d columns and there are %d rows", v, len(row), verts)
+		}
+		matrix[v] = make([]float64, verts)
+		for w, str := range row {
+			str = strings.TrimSpace(str)
+			if len(str) == 0 || strings.EqualFold(str, "inf") {
+				matrix[v][w] = infinity
+				continue
+			}
+			weight, err := strconv.ParseFloat(str, 64)
+			if err != nil || math.IsNaN(weight) {
+				return "", fmt.Errorf("adjacency matrix row %d column %d %q is not a weight", v, w, str)
+			}
+			if weight < 0 {
+				return "", fmt.Errorf("adjacency matrix row %d column %d weight %g is negative", v, w, weight)
+			}
+			if weight == 0 {
+				weight = infinity
+			}
+			matrix[v][w] = weight
+		}
+	}
+
+	var weights strings.Builder
+	edges := 0
+	for v := 0; v < verts; v++ {
+		for w := v + 1; w < verts; w++ {
+			if matrix[v][w] != matrix[w][v] {
+				return "", fmt.Errorf("adjacency matrix is not symmetric, row %d column %d is %g and row %d column %d is %g",
+					v, w, matrix[v][w], w, v, matrix[w][v])
+			}
+			if matrix[v][w] < infinity {
+				fmt.Fprintf(&weights, "%d,%d,%s\n", v, w, strconv.FormatFloat(matrix[v][w], 'g', -1, 64))
+				edges++
+			}
+		}
+	}
+
+	// Circular layout, vertex 0 on the right and the others counterclockwise
+	p.location = make([]complex128, verts)
+	for v := range p.location {
+		p.location[v] = cmplx.Rect(1, 2*math.Pi*float64(v)/float64(verts))
+	}
+	p.Endpoints = fitEndpoints(p.location)
+	p.plot.Imported = fmt.Sprintf("Adjacency matrix import: %d vertices, %d edges", verts, edges)
+
+	return weights.String(), nil
+}
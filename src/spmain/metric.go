@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+// Distance metrics between vertex locations
+const (
+	metricEuclidean = "euclidean" // straight line distance in the plane
+	metricToroidal  = "toroidal"  // straight line distance with x and y wrapping at the bounds
+	metricHaversine = "haversine" // great circle distance in km, x is longitude and y is latitude
+	metricManhattan = "manhattan" // sum of the x and y distances
+	metricChebyshev = "chebyshev" // larger of the x and y distances
+)
+
+// metrics are the distance metrics in the order the SP length is reported in them
+var metrics = []string{metricEuclidean, metricToroidal, metricHaversine, metricManhattan, metricChebyshev}
+
+// Type to contain the SP length measured in one of the metrics
+type MetricT struct {
+	Metric   string // distance metric
+	Distance string // SP length in the metric, haversine is in km
+	Active   bool   // true if the SP was optimized in this metric
+}
+
+// parseMetric validates the metric form value, the empty value is Euclidean
+func parseMetric(metric string) (string, error) {
+	switch metric {
+	case "":
+		return metricEuclidean, nil
+	case metricEuclidean, metricToroidal, metricHaversine, metricManhattan, metricChebyshev:
+		return metric, nil
+	}
+	return "", fmt.Errorf("metric %q must be one of %s", metric, strings.Join(metrics, ", "))
+}
+
+// wrap returns the shortest displacement from a to b on the torus made by joining
+// the opposite edges of the bounds
+func (ep *Endpoints) wrap(a, b complex128) complex128 {
+	d := b - a
+	dx, dy := real(d), imag(d)
+	width, height := ep.xmax-ep.xmin, ep.ymax-ep.ymin
+	if dx > width/2 {
+		dx -= width
+	} else if dx < -width/2 {
+		dx += width
+	}
+	if dy > height/2 {
+		dy -= height
+	} else if dy < -height/2 {
+		dy += height
+	}
+	return complex(dx, dy)
+}
+
+// distance returns the distance between the locations a and b in the metric
+func (ep *Endpoints) distance(metric string, a, b complex128) float64 {
+	switch metric {
+	case metricToroidal:
+		return cmplx.Abs(ep.wrap(a, b))
+	case metricHaversine:
+		return haversine(a, b)
+	case metricManhattan:
+		return math.Abs(real(b-a)) + math.Abs(imag(b-a))
+	case metricChebyshev:
+		return math.Max(math.Abs(real(b-a)), math.Abs(imag(b-a)))
+	}
+	return cmplx.Abs(b - a)
+}
+
+// reportMetrics sets the SP length in every metric from the lengths summed by plotSP.
+// The lengths follow the vertex locations, custom edge weights are not used.
+func (dsp *DijksraSP) reportMetrics() {
+	dsp.plot.MetricDistances = make([]MetricT, len(metrics))
+	for i, metric := range metrics {
+		distance := dsp.plot.withUnits(fmt.Sprintf("%.2f", dsp.lengths[i]))
+		if metric == metricHaversine {
+			distance = fmt.Sprintf("%.2f km", dsp.lengths[i])
+		}
+		dsp.plot.MetricDistances[i] = MetricT{Metric: metric, Distance: distance, Active: metric == dsp.metric}
+	}
+}
+
+// isWrapped returns true if the toroidal shortest displacement from a to b crosses the bounds
+func (ep *Endpoints) isWrapped(a, b complex128) bool {
+	d := ep.wrap(a, b)
+	return math.Abs(real(d)-real(b-a)) > 0 || math.Abs(imag(d)-imag(b-a)) > 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// metricCases are the expected weights of the edge from (1,2) to (4,-2) in the metrics
+// of findDistances, a 3-4-5 triangle
+var metricCases = []struct {
+	metric string
+	weight float64
+}{
+	{metricEuclidean, 5},
+	{metricManhattan, 7},
+	{metricChebyshev, 4},
+}
+
+// TestMetrics checks the edge weight of a known pair of vertices in each metric and
+// that plotSP sums the SP distance in the same metric
+func TestMetrics(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: -5, xmax: 5, ymax: 5}
+	location := []complex128{complex(1, 2), complex(4, -2)}
+	for _, c := range metricCases {
+		plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+		primmst := &PrimMST{plot: plot, location: location, metric: c.metric, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if got := primmst.graph[0][1]; lessDistance(got, c.weight) || lessDistance(c.weight, got) {
+			t.Fatalf("%s weight %g, want %g", c.metric, got, c.weight)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotGrid(); err != nil {
+			t.Fatal(err)
+		}
+		dsp := newDijkstraSP(primmst)
+		dsp.source, dsp.target = 0, 1
+		dsp.searchSP()
+		if err := dsp.plotSP(); err != nil {
+			t.Fatal(err)
+		}
+		if want := plot.withUnits(fmt.Sprintf("%.2f", c.weight)); plot.DistanceSP != want {
+			t.Fatalf("%s SP distance %s, want %s", c.metric, plot.DistanceSP, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// vertexName returns the name of vertex v, or its index if the graph has no names
+func vertexName(names []string, v int) string {
+	if v < len(names) && len(names[v]) > 0 {
+		return names[v]
+	}
+	return strconv.Itoa(v)
+}
+
+// parseVertex gets the vertex from its index or, if the graph has names, its name.
+// Duplicate names select the first vertex with the name.
+func parseVertex(names []string, str string) (int, error) {
+	v, err := strconv.Atoi(str)
+	if err == nil {
+		return v, nil
+	}
+	str = strings.TrimSpace(str)
+	for i, name := range names {
+		if name == str {
+			return i, nil
+		}
+	}
+	if len(names) > 0 {
+		return 0, fmt.Errorf("vertex %q is not a vertex index or name", str)
+	}
+	return 0, err
+}
+
+// plotRoute writes the SP vertices from source to target by name for the route text
+func (dsp *DijksraSP) plotRoute(path []int) {
+	route := make([]string, len(path))
+	for i, v := range path {
+		route[i] = vertexName(dsp.names, v)
+	}
+	dsp.plot.Route = strings.Join(route, " -> ")
+}
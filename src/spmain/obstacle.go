@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseObstacles gets the rectangular obstacles from the HTML form, one x1,y1,x2,y2
+// rectangle per line.  findDistances gives the edges meeting an obstacle infinite
+// weight, so the MST and SP route around them.
+func (p *PrimMST) parseObstacles(r *http.Request) error {
+	obstacles := strings.TrimSpace(r.PostFormValue("obstacles"))
+	if len(obstacles) == 0 {
+		return nil
+	}
+	p.plot.Obstacles = obstacles
+
+	for _, line := range strings.Split(obstacles, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		values := strings.Split(line, ",")
+		if len(values) != 4 {
+			return fmt.Errorf("obstacle %q must be x1,y1,x2,y2", line)
+		}
+		corners := make([]float64, len(values))
+		for i, str := range values {
+			var err error
+			if corners[i], err = strconv.ParseFloat(strings.TrimSpace(str), 64); err != nil {
+				fmt.Printf("String %s conversion to float error: %v\n", str, err)
+				return err
+			}
+		}
+		// Either pair of opposite corners makes the same rectangle
+		ob := Endpoints{xmin: corners[0], ymin: corners[1], xmax: corners[2], ymax: corners[3]}
+		if ob.xmin > ob.xmax {
+			ob.xmin, ob.xmax = ob.xmax, ob.xmin
+		}
+		if ob.ymin > ob.ymax {
+			ob.ymin, ob.ymax = ob.ymax, ob.ymin
+		}
+		p.obstacles = append(p.obstacles, ob)
+	}
+
+	return nil
+}
+
+// meets returns true if the line segment from a to b meets the closed rectangle,
+// which includes a segment touching a corner or running along a side.  The segment
+// is clipped to the rectangle one side at a time (Liang-Barsky) and meets it if a
+// part, possibly a single point, is left.
+func (ob *Endpoints) meets(a, b complex128) bool {
+	d := b - a
+	t0, t1 := 0.0, 1.0
+	// Each side limits the segment parameter t to the inside of the rectangle
+	sides := [4][2]float64{
+		{-real(d), real(a) - ob.xmin},
+		{real(d), ob.xmax - real(a)},
+		{-imag(d), imag(a) - ob.ymin},
+		{imag(d), ob.ymax - imag(a)},
+	}
+	for _, side := range sides {
+		p, q := side[0], side[1]
+		if p == 0 {
+			// parallel to the side and outside it
+			if q < 0 {
+				return false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return false
+			}
+			if t > t0 {
+				t0 = t
+			}
+		} else {
+			if t < t0 {
+				return false
+			}
+			if t < t1 {
+				t1 = t
+			}
+		}
+	}
+	return true
+}
+
+// blocked returns true if the edge between v and w meets an obstacle.  On a torus the
+// edge is the segment of the shortest wrapped displacement.
+func (p *PrimMST) blocked(v, w int) bool {
+	a, b := p.location[v], p.location[w]
+	for i := range p.obstacles {
+		ob := &p.obstacles[i]
+		if p.metric == metricToroidal && p.isWrapped(a, b) {
+			d := p.wrap(a, b)
+			if ob.meets(a, a+d) || ob.meets(b, b-d) {
+				return true
+			}
+			continue
+		}
+		if ob.meets(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// plotObstacles fills each obstacle limited to the plotted region.  The obstacles
+// are below the edges and vertices.  CSS colors the obstacles.
+func (p *PrimMST) plotObstacles() {
+	view := p.plot.view
+	width := p.plot.size() * p.plot.supersample
+	for _, ob := range p.obstacles {
+		xmin, ymin := math.Max(ob.xmin, view.xmin), math.Max(ob.ymin, view.ymin)
+		xmax, ymax := math.Min(ob.xmax, view.xmax), math.Min(ob.ymax, view.ymax)
+		if xmin > xmax || ymin > ymax {
+			continue
+		}
+		top, left := p.plot.toCell(xmin, ymax)
+		bottom, right := p.plot.toCell(xmax, ymin)
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		for row := top; row <= bottom; row++ {
+			for col := left; col <= right; col++ {
+				p.plot.paint(row*width+col, "obstacle")
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+// obstacleCases are segments checked against the obstacle from (0,0) to (2,1).  The
+// obstacle is closed, so touching a corner or running along a side meets it.
+var obstacleCases = []struct {
+	name  string
+	a, b  complex128
+	meets bool
+}{
+	{"crossing", complex(-1, 0.5), complex(3, 0.5), true},
+	{"inside", complex(0.5, 0.5), complex(1.5, 0.5), true},
+	{"one end inside", complex(1, 0.5), complex(1, 4), true},
+	{"diagonal through corners", complex(-1, -0.5), complex(3, 1.5), true},
+	{"touching a corner", complex(-1, 0), complex(1, 2), true},
+	{"ending on a corner", complex(-1, -1), complex(0, 0), true},
+	{"along the bottom side", complex(0.5, 0), complex(1.5, 0), true},
+	{"along and past the top side", complex(-1, 1), complex(3, 1), true},
+	{"ending on a side", complex(1, -1), complex(1, 0), true},
+	{"above", complex(-1, 1.5), complex(3, 1.5), false},
+	{"collinear with a side, apart", complex(3, 0), complex(4, 0), false},
+	{"passing a corner", complex(-1, 0.5), complex(0.5, 2), false},
+	{"point outside", complex(5, 5), complex(5, 5), false},
+}
+
+// TestObstacles checks the segment and obstacle intersection cases
+func TestObstacles(t *testing.T) {
+	ob := &Endpoints{xmin: 0, ymin: 0, xmax: 2, ymax: 1}
+	for _, c := range obstacleCases {
+		for _, reversed := range []bool{false, true} {
+			a, b := c.a, c.b
+			if reversed {
+				a, b = b, a
+			}
+			if got := ob.meets(a, b); got != c.meets {
+				t.Fatalf("segment %s from %v to %v meets the obstacle %v, want %v", c.name, a, b, got, c.meets)
+			}
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// OpenAPI 3 document elements used by /api/openapi.json
+type openAPISchema struct {
+	Type       string                    `json:"type,omitempty"`
+	Format     string                    `json:"format,omitempty"`
+	Ref        string                    `json:"$ref,omitempty"`
+	Enum       []string                  `json:"enum,omitempty"`
+	Items      *openAPISchema            `json:"items,omitempty"`
+	Properties map[string]*openAPISchema `json:"properties,omitempty"`
+	Desc       string                    `json:"description,omitempty"`
+}
+
+type openAPIMedia struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Content map[string]openAPIMedia `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                  `json:"description"`
+	Content     map[string]openAPIMedia `json:"content,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name   string         `json:"name"`
+	In     string         `json:"in"`
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIPath struct {
+	Get  *openAPIOperation `json:"get,omitempty"`
+	Post *openAPIOperation `json:"post,omitempty"`
+}
+
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]openAPIPath `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPIField is a form value of an API request
+type openAPIField struct {
+	name   string
+	schema *openAPISchema
+}
+
+// openAPIBodyFields are the form values read with PostFormValue.  A GET query cannot
+// set them, so they are only documented in the POST form body.
+var openAPIBodyFields = map[string]bool{
+	"obstacles": true, "edgeprob": true, "edgeweights": true, "edgesonly": true, "bellmanford": true, "knn": true,
+	"hoporder": true, "fullgraph": true, "cachesp": true,
+	"clipxmin": true, "clipymin": true, "clipxmax": true, "clipymax": true,
+	"excludex": true, "excludey": true, "excluderadius": true,
+}
+
+// Form values shared by the API requests
+var (
+	fieldsGraph = []openAPIField{
+		{"slot", &openAPISchema{Type: "string", Desc: "graph slot, empty for the default graph"}},
+		{"graphblock", &openAPISchema{Type: "integer", Desc: "graph block of the vertex file"}},
+	}
+	fieldsMST = withFields(fieldsGraph,
+		openAPIField{"metric", &openAPISchema{Type: "string", Enum: metrics, Desc: "distance metric, euclidean if empty"}},
+		openAPIField{"edgeweights", &openAPISchema{Type: "string", Desc: "custom edge weights, one v,w,weight per line"}},
+		openAPIField{"edgesonly", &openAPISchema{Type: "string", Enum: []string{"on"}, Desc: "only the weighted edges connect the vertices"}},
+		openAPIField{"bellmanford", &openAPISchema{Type: "string", Enum: []string{"on"}, Desc: "allow negative custom edge weights"}},
+		openAPIField{"obstacles", &openAPISchema{Type: "string", Desc: "obstacle rectangles the edges cannot cross, one x1,y1,x2,y2 per line"}},
+		openAPIField{"edgeprob", &openAPISchema{Type: "number", Desc: "probability of each edge in an Erdős–Rényi random graph, the complete graph if empty"}},
+		openAPIField{"seed", &openAPISchema{Type: "integer", Desc: "seed drawing the edges of the edge probability"}},
+		openAPIField{"knn", &openAPISchema{Type: "integer", Desc: "neighbors of each vertex in the kNN graph, the complete graph if empty"}},
+		openAPIField{"startvert", &openAPISchema{Type: "integer", Desc: "MST start vertex, 0 if empty"}},
+	)
+	fieldsSP = withFields(fieldsMST,
+		openAPIField{"sourcevert", &openAPISchema{Type: "integer", Desc: "SP source vertex"}},
+		openAPIField{"targetvert", &openAPISchema{Type: "integer", Desc: "SP target vertex"}},
+		openAPIField{"hoporder", &openAPISchema{Type: "string", Enum: []string{hopsFewest, hopsMost}, Desc: "break equal distances by the fewest or the most hops"}},
+		openAPIField{"fullgraph", &openAPISchema{Type: "string", Enum: []string{"on"}, Desc: "search every edge of the graph, not only the MST"}},
+		openAPIField{"cachesp", &openAPISchema{Type: "string", Enum: []string{"on"}, Desc: "reuse the SP tree of the source"}},
+	)
+	fieldsOverlay = []openAPIField{
+		{"transparent", &openAPISchema{Type: "string", Enum: []string{"on", "1"}}},
+		{"edgecolor", &openAPISchema{Type: "string", Desc: "hex color #rrggbb"}},
+		{"vertexcolor", &openAPISchema{Type: "string", Desc: "hex color #rrggbb"}},
+	}
+)
+
+// withFields returns the form values of base followed by the extra form values
+func withFields(base []openAPIField, extra ...openAPIField) []openAPIField {
+	fields := make([]openAPIField, 0, len(base)+len(extra))
+	fields = append(fields, base...)
+	return append(fields, extra...)
+}
+
+// Methods of the API paths.  The form values of a GET are its query parameters.
+var (
+	methodsForm = []string{http.MethodGet, http.MethodPost}
+	methodsGet  = []string{http.MethodGet}
+	methodsPost = []string{http.MethodPost}
+)
+
+// openAPIEndpoint describes the methods, the form values and the response of an API path
+type openAPIEndpoint struct {
+	pattern  string
+	summary  string
+	methods  []string
+	fields   []openAPIField
+	response interface{} // JSON response type, nil for the media type
+	media    string      // media type of a response that is not JSON
+	request  interface{} // JSON request type, nil for the form values
+}
+
+// openAPIEndpoints are the documented API paths.  main checks that each of them is
+// registered so the document cannot list a path the server does not handle.
+var openAPIEndpoints = []openAPIEndpoint{
+	{patternProfile, "SP distance, profile and polyline between two vertices", methodsForm, fieldsSP, ProfileT{}, "", nil},
+	{patternRepro, "reproducible test case bundle of an SP query, download=1 for an attachment", methodsForm,
+		withFields(fieldsSP,
+			openAPIField{"clipxmin", &openAPISchema{Type: "number", Desc: "clip rectangle limiting the SP search"}},
+			openAPIField{"clipymin", &openAPISchema{Type: "number"}},
+			openAPIField{"clipxmax", &openAPISchema{Type: "number"}},
+			openAPIField{"clipymax", &openAPISchema{Type: "number"}},
+			openAPIField{"excludex", &openAPISchema{Type: "number", Desc: "center x of the circle left out of the SP search"}},
+			openAPIField{"excludey", &openAPISchema{Type: "number", Desc: "center y of the circle"}},
+			openAPIField{"excluderadius", &openAPISchema{Type: "number", Desc: "radius of the circle"}},
+			openAPIField{"contract", &openAPISchema{Type: "string", Enum: []string{"on"}, Desc: "search the contracted graph"}},
+			openAPIField{"download", &openAPISchema{Type: "string", Enum: []string{"1"}}}), ReproT{}, "", nil},
+	{patternMST, "MST edges and total distance", methodsForm, fieldsMST, MSTT{}, "", nil},
+	{patternVerifyMST, "check of the MST cut property", methodsForm, fieldsMST, VerifyMSTT{}, "", nil},
+	{patternHistogram, "edge length statistics and histogram", methodsForm,
+		withFields(fieldsMST,
+			openAPIField{"bins", &openAPISchema{Type: "integer", Desc: "number of bins"}},
+			openAPIField{"edges", &openAPISchema{Type: "string", Enum: []string{"mst", "graph"}}}), HistogramT{}, "", nil},
+	{patternNearest, "k nearest vertices and their distances", methodsForm,
+		withFields(fieldsGraph,
+			openAPIField{"vertex", &openAPISchema{Type: "integer", Desc: "query vertex"}},
+			openAPIField{"k", &openAPISchema{Type: "integer", Desc: "number of neighbors, 5 if empty"}}), NearestT{}, "", nil},
+	{patternGridPoint, "graph coordinates and nearest vertex of a grid cell", methodsForm,
+		withFields(fieldsGraph,
+			openAPIField{"row", &openAPISchema{Type: "integer"}},
+			openAPIField{"col", &openAPISchema{Type: "integer"}},
+			openAPIField{"gridsize", &openAPISchema{Type: "integer", Desc: "grid rows and columns, 300 if empty"}},
+			openAPIField{"orientation", &openAPISchema{Type: "string", Enum: []string{orientationMath, orientationScreen}}}), GridPointT{}, "", nil},
+	{patternGraphSVG, "MST drawn as SVG", methodsForm,
+		withFields(withFields(fieldsMST, fieldsOverlay...),
+			openAPIField{"units", &openAPISchema{Type: "string"}}), nil, "image/svg+xml", nil},
+	{patternAllPairs, "all-pairs SP distances of the MST or kNN graph as a CSV matrix", methodsForm,
+		withFields(fieldsMST,
+			openAPIField{"sparse", &openAPISchema{Type: "string", Enum: []string{sparseMST, sparseKNN}, Desc: "sparsified graph, mst if empty"}},
+			openAPIField{"k", &openAPISchema{Type: "integer", Desc: "neighbors of each vertex in the kNN graph, 4 if empty"}},
+			openAPIField{"download", &openAPISchema{Type: "string", Enum: []string{"1"}}}), nil, "text/csv", nil},
+	{patternDijkstraSVG, "MST and SP between sourcevert and targetvert drawn as SVG", methodsForm,
+		withFields(withFields(fieldsSP, fieldsOverlay...),
+			openAPIField{"pathcolor", &openAPISchema{Type: "string", Desc: "hex color #rrggbb, yellow if empty"}}), nil, "image/svg+xml", nil},
+	{patternSPDebug, "distance and SP tree parent of every vertex after the SP search", methodsForm, fieldsSP, SPDebugT{}, "", nil},
+	{patternDOT, "graph, MST and optional SP as GraphViz DOT, download=1 for an attachment", methodsForm,
+		withFields(fieldsSP,
+			openAPIField{"edges", &openAPISchema{Type: "string", Enum: []string{"mst", "graph"}, Desc: "edges to write, mst if empty"}},
+			openAPIField{"download", &openAPISchema{Type: "string", Enum: []string{"1"}}}), nil, "text/vnd.graphviz", nil},
+	{patternSP, "SP path, coordinates and distance between two random vertices of a JSON request", methodsPost,
+		nil, SPT{}, "", SPRequestT{}},
+	{patternGraphs, "named graph slots and the URLs loading them", methodsGet, nil, GraphListT{}, "", nil},
+}
+
+// openAPISchemaOf returns the schema of the JSON encoding of t.  Structs are added to
+// the components and referenced, so the document follows the response types.
+func openAPISchemaOf(t reflect.Type, components map[string]*openAPISchema) *openAPISchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return openAPISchemaOf(t.Elem(), components)
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: openAPISchemaOf(t.Elem(), components)}
+	case reflect.Struct:
+		schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if len(name) == 0 {
+				name = f.Name
+			}
+			schema.Properties[name] = openAPISchemaOf(f.Type, components)
+		}
+		if len(t.Name()) == 0 {
+			return schema
+		}
+		components[t.Name()] = schema
+		return &openAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &openAPISchema{}
+}
+
+// openAPIDocument builds the OpenAPI 3 document of the API endpoints
+func openAPIDocument() *openAPIDoc {
+	doc := &openAPIDoc{OpenAPI: "3.0.3", Paths: make(map[string]openAPIPath)}
+	doc.Info.Title = "Dijkstra Shortest Paths API"
+	doc.Info.Version = "1.0.0"
+	doc.Components.Schemas = make(map[string]*openAPISchema)
+
+	apiError := openAPIResponse{
+		Description: "error with a stable code: " + strings.Join([]string{apiInvalidInput, apiNoGraph, apiNoPath, apiUnreachable, apiInternal}, ", "),
+		Content: map[string]openAPIMedia{
+			"application/json": {Schema: openAPISchemaOf(reflect.TypeOf(APIErrorT{}), doc.Components.Schemas)},
+		},
+	}
+	for _, ep := range openAPIEndpoints {
+		ok := openAPIResponse{Description: ep.summary}
+		if ep.response == nil {
+			ok.Content = map[string]openAPIMedia{ep.media: {Schema: &openAPISchema{Type: "string"}}}
+		} else {
+			ok.Content = map[string]openAPIMedia{
+				"application/json": {Schema: openAPISchemaOf(reflect.TypeOf(ep.response), doc.Components.Schemas)},
+			}
+		}
+		responses := map[string]openAPIResponse{"200": ok, "default": apiError}
+
+		var path openAPIPath
+		for _, method := range ep.methods {
+			op := &openAPIOperation{Summary: ep.summary, Responses: responses}
+			switch method {
+			case http.MethodGet:
+				// A GET query sets the form values read with FormValue
+				for _, f := range ep.fields {
+					if !openAPIBodyFields[f.name] {
+						op.Parameters = append(op.Parameters, openAPIParameter{Name: f.name, In: "query", Schema: f.schema})
+					}
+				}
+				path.Get = op
+			case http.MethodPost:
+				if ep.request != nil {
+					op.RequestBody = &openAPIBody{Content: map[string]openAPIMedia{
+						"application/json": {Schema: openAPISchemaOf(reflect.TypeOf(ep.request), doc.Components.Schemas)},
+					}}
+				} else if len(ep.fields) > 0 {
+					form := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+					for _, f := range ep.fields {
+						form.Properties[f.name] = f.schema
+					}
+					op.RequestBody = &openAPIBody{Content: map[string]openAPIMedia{
+						"application/x-www-form-urlencoded": {Schema: form},
+					}}
+				}
+				path.Post = op
+			}
+		}
+		doc.Paths[ep.pattern] = path
+	}
+	return doc
+}
+
+// checkOpenAPI returns an error if a documented path is not handled by the mux
+func checkOpenAPI(mux *http.ServeMux) error {
+	for _, ep := range openAPIEndpoints {
+		r, err := http.NewRequest(ep.methods[0], ep.pattern, nil)
+		if err != nil {
+			return err
+		}
+		if _, pattern := mux.Handler(r); pattern != ep.pattern {
+			return fmt.Errorf("OpenAPI path %s is not registered", ep.pattern)
+		}
+	}
+	return nil
+}
+
+// HTTP handler for /api/openapi.json connections.  It returns the OpenAPI 3
+// description of the API.
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, openAPIDocument())
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// TestOpenAPI checks that the document lists the methods of each path and only the
+// form values its handler reads.  A GET query has no values read from the POST body.
+func TestOpenAPI(t *testing.T) {
+	doc := openAPIDocument()
+
+	profile := doc.Paths[patternProfile]
+	if profile.Get == nil || profile.Post == nil {
+		t.Fatalf("%s methods GET %v POST %v, want both", patternProfile, profile.Get != nil, profile.Post != nil)
+	}
+	form := profile.Post.RequestBody.Content["application/x-www-form-urlencoded"].Schema.Properties
+	for _, name := range []string{"sourcevert", "targetvert", "obstacles", "fullgraph", "hoporder", "seed"} {
+		if form[name] == nil {
+			t.Errorf("%s POST has no %s", patternProfile, name)
+		}
+	}
+	for _, name := range []string{"clipxmin", "excluderadius", "contract"} {
+		if form[name] != nil {
+			t.Errorf("%s POST has %s, its handler does not read it", patternProfile, name)
+		}
+	}
+	for _, p := range profile.Get.Parameters {
+		if openAPIBodyFields[p.Name] {
+			t.Errorf("%s GET has %s, it is only read from the POST body", patternProfile, p.Name)
+		}
+	}
+
+	repro := doc.Paths[patternRepro].Post.RequestBody.Content["application/x-www-form-urlencoded"].Schema.Properties
+	for _, name := range []string{"clipxmin", "excluderadius", "contract"} {
+		if repro[name] == nil {
+			t.Errorf("%s POST has no %s", patternRepro, name)
+		}
+	}
+
+	if sp := doc.Paths[patternSP]; sp.Get != nil || sp.Post == nil {
+		t.Errorf("%s methods GET %v POST %v, want POST only", patternSP, sp.Get != nil, sp.Post != nil)
+	}
+	if graphs := doc.Paths[patternGraphs]; graphs.Get == nil || graphs.Post != nil {
+		t.Errorf("%s methods GET %v POST %v, want GET only", patternGraphs, graphs.Get != nil, graphs.Post != nil)
+	}
+}
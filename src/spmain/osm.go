@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadius     = 6371.0 // mean Earth radius in km for the haversine distance
+	maxOSMVertices  = 500    // maximum nodes used by the ways, the graph matrix is V x V
+	osmDefaultUnits = "km"   // units of the haversine distances
+)
+
+// haversine returns the great circle distance in km between the locations, which
+// hold the longitude as x and the latitude as y in degrees
+func haversine(a, b complex128) float64 {
+	lat1, lat2 := imag(a)*math.Pi/180, imag(b)*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := (real(b) - real(a)) * math.Pi / 180
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// osmNode is a node of the OSM nodes file
+type osmNode struct {
+	location  complex128 // longitude as x and latitude as y
+	elevation float64    // optional elevation, 0 if not given
+}
+
+// readOSMNodes reads the "id,lat,lon" or "id,lat,lon,elevation" lines of the nodes
+// file.  A header line is skipped.  It returns the nodes and if they have elevations.
+func readOSMNodes(r io.Reader) (map[string]osmNode, bool, error) {
+	nodes := make(map[string]osmNode)
+	hasElevation := false
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		values := strings.Split(line, ",")
+		if len(values) != 3 && len(values) != 4 {
+			return nil, false, fmt.Errorf("OSM nodes line %d %q must be id,lat,lon or id,lat,lon,elevation", n, line)
+		}
+		id := strings.TrimSpace(values[0])
+		lat, err := strconv.ParseFloat(strings.TrimSpace(values[1]), 64)
+		lon, errLon := strconv.ParseFloat(strings.TrimSpace(values[2]), 64)
+		if err != nil || errLon != nil {
+			if n == 1 {
+				continue
+			}
+			return nil, false, fmt.Errorf("OSM nodes line %d %q lat and lon must be numbers", n, line)
+		}
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, false, fmt.Errorf("OSM node %s lat %g, lon %g is out of range", id, lat, lon)
+		}
+		if _, ok := nodes[id]; ok {
+			return nil, false, fmt.Errorf("OSM node id %s is duplicated", id)
+		}
+		node := osmNode{location: complex(lon, lat)}
+		if len(values) == 4 {
+			node.elevation, err = strconv.ParseFloat(strings.TrimSpace(values[3]), 64)
+			if err != nil {
+				return nil, false, fmt.Errorf("OSM nodes line %d %q elevation must be a number", n, line)
+			}
+			hasElevation = true
+		}
+		nodes[id] = node
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+	return nodes, hasElevation, nil
+}
+
+// importOSM reads the OSM nodes and ways files.  Each ways line is a comma-separated
+// sequence of node ids, consecutive ids become an edge.  The nodes used by the ways
+// become the vertices with the longitude as x and the latitude as y, and the optional
+// node elevations become the vertex attributes.  It returns the haversine edge
+// weights as "v,w,weight" lines for applyWeights and the edge count.
+func (p *PrimMST) importOSM(nodesFile, waysFile io.Reader) (string, int, error) {
+	nodes, hasElevation, err := readOSMNodes(nodesFile)
+	if err != nil {
+		return "", 0, err
+	}
+
+	vertex := make(map[string]int)
+	p.location = make([]complex128, 0)
+	p.attr = nil
+	type pair struct{ v, w int }
+	edges := make(map[pair]bool)
+	weights := make([]string, 0)
+
+	scanner := bufio.NewScanner(waysFile)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		prev := -1
+		for _, id := range strings.Split(line, ",") {
+			id = strings.TrimSpace(id)
+			node, ok := nodes[id]
+			if !ok {
+				return "", 0, fmt.Errorf("OSM ways line %d node id %q is not in the nodes file", n, id)
+			}
+			v, ok := vertex[id]
+			if !ok {
+				v = len(p.location)
+				vertex[id] = v
+				p.location = append(p.location, node.location)
+				if hasElevation {
+					p.attr = append(p.attr, node.elevation)
+				}
+			}
+			if prev >= 0 && prev != v {
+				key := pair{prev, v}
+				if v < prev {
+					key = pair{v, prev}
+				}
+				if !edges[key] {
+					edges[key] = true
+					weights = append(weights, fmt.Sprintf("%d,%d,%f", key.v, key.w, haversine(p.location[key.v], p.location[key.w])))
+				}
+			}
+			prev = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	if len(p.location) < 2 {
+		return "", 0, fmt.Errorf("OSM ways use %d nodes, at least 2 are needed", len(p.location))
+	}
+	if len(p.location) > maxOSMVertices {
+		return "", 0, fmt.Errorf("OSM ways use %d nodes, the maximum is %d", len(p.location), maxOSMVertices)
+	}
+	p.Endpoints = fitEndpoints(p.location)
+
+	return strings.Join(weights, "\n"), len(edges), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// colorPattern is a hex color, the # is optional since it must be escaped in a URL
+var colorPattern = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+
+// parseColor validates the hex color from the form and returns it as #rrggbb, or the
+// empty string for the default color.  Only hex colors are accepted so the value
+// cannot inject CSS into the page or the SVG style.
+func parseColor(name, str string) (string, error) {
+	str = strings.TrimSpace(str)
+	if len(str) == 0 {
+		return "", nil
+	}
+	if !colorPattern.MatchString(str) {
+		return "", fmt.Errorf("%s %q must be a hex color #rrggbb", name, str)
+	}
+	return "#" + strings.TrimPrefix(str, "#"), nil
+}
+
+// parseOverlay gets the transparent background and the edge, path and vertex colors
+// from the HTML form or the URL.  A transparent plot only draws the vertices, edges
+// and paths so it can be composited over a map image.
+func (plot *PlotT) parseOverlay(r *http.Request) error {
+	if v := r.FormValue("transparent"); v == "on" || v == "1" {
+		plot.Transparent = "checked"
+	}
+	var err error
+	if plot.EdgeColor, err = parseColor("edge color", r.FormValue("edgecolor")); err != nil {
+		return err
+	}
+	if plot.PathColor, err = parseColor("path color", r.FormValue("pathcolor")); err != nil {
+		return err
+	}
+	if plot.VertexColor, err = parseColor("vertex color", r.FormValue("vertexcolor")); err != nil {
+		return err
+	}
+	return nil
+}
+
+// svgOverlayStyle returns the SVG style rules of the custom colors, they follow
+// svgStyle and override its colors
+func (plot *PlotT) svgOverlayStyle() string {
+	var b strings.Builder
+	if len(plot.EdgeColor) > 0 {
+		fmt.Fprintf(&b, ".edge { stroke: %s; }\n", plot.EdgeColor)
+	}
+	if len(plot.PathColor) > 0 {
+		fmt.Fprintf(&b, ".edgeSP { stroke: %s; }\n", plot.PathColor)
+	}
+	if len(plot.VertexColor) > 0 {
+		fmt.Fprintf(&b, ".vertex { fill: %s; }\n", plot.VertexColor)
+	}
+	return b.String()
+}
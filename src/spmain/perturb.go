@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// perturbVertices moves percent of the saved vertices to new random locations of rng
+// within the endpoints and keeps the others fixed.  The vertex indices do not change, so the
+// source and target stay valid.  A file with several graph blocks is saved with only
+// the perturbed block.
+func (p *PrimMST) perturbVertices(percent float64, rng *rand.Rand) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("perturb %g%% must be 0-100", percent)
+	}
+	n := int(math.Round(percent / 100 * float64(len(p.location))))
+	p.moved = rng.Perm(len(p.location))[:n]
+
+	delx := p.xmax - p.xmin
+	dely := p.ymax - p.ymin
+	for _, v := range p.moved {
+		x := p.xmin + delx*rng.Float64()
+		y := p.ymin + dely*rng.Float64()
+		p.location[v] = complex(x, y)
+	}
+	p.plot.Perturbed = fmt.Sprintf("%d of %d vertices moved", n, len(p.location))
+
+	return nil
+}
+
+// plotMoved marks the vertices moved by perturbVertices.  CSS colors the moved vertex.
+func (p *PrimMST) plotMoved() {
+	for _, v := range p.moved {
+		p.plot.setCell(real(p.location[v]), imag(p.location[v]), "vertexMoved")
+	}
+}
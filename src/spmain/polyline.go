@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+const polylinePrecision = 1e5 // coordinate scale of the encoded polyline, 5 decimal places
+
+// encodePolyline encodes the locations with the encoded polyline algorithm of the web
+// map libraries.  Each point is written as latitude then longitude, so y is the
+// latitude and x is the longitude as in the haversine metric.
+func encodePolyline(location []complex128) string {
+	var b strings.Builder
+	// encode writes the signed difference in 5-bit chunks, lowest chunk first
+	encode := func(delta int64) {
+		value := delta << 1
+		if delta < 0 {
+			value = ^value
+		}
+		for value >= 0x20 {
+			b.WriteByte(byte((0x20 | (value & 0x1f)) + 63))
+			value >>= 5
+		}
+		b.WriteByte(byte(value + 63))
+	}
+
+	var prevLat, prevLng int64
+	for _, z := range location {
+		lat := int64(math.Round(imag(z) * polylinePrecision))
+		lng := int64(math.Round(real(z) * polylinePrecision))
+		encode(lat - prevLat)
+		encode(lng - prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return b.String()
+}
+
+// polyline returns the SP found by findSP as an encoded polyline
+func (dsp *DijksraSP) polyline() string {
+	path := dsp.pathVertices()
+	location := make([]complex128, len(path))
+	for i, v := range path {
+		location[i] = dsp.location[v]
+	}
+	return encodePolyline(location)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// decodePolyline decodes the encoded polyline into locations, x the longitude and y
+// the latitude, the reverse of encodePolyline
+func decodePolyline(s string) ([]complex128, error) {
+	// decode reads one signed difference from its 5-bit chunks, lowest chunk first
+	decode := func() (int64, error) {
+		var value int64
+		for shift := 0; ; shift += 5 {
+			if len(s) == 0 {
+				return 0, fmt.Errorf("polyline ends inside a value")
+			}
+			chunk := int64(s[0]) - 63
+			s = s[1:]
+			value |= (chunk & 0x1f) << shift
+			if chunk < 0x20 {
+				break
+			}
+		}
+		if value&1 == 1 {
+			return ^(value >> 1), nil
+		}
+		return value >> 1, nil
+	}
+
+	location := make([]complex128, 0)
+	var lat, lng int64
+	for len(s) > 0 {
+		dLat, err := decode()
+		if err != nil {
+			return nil, err
+		}
+		dLng, err := decode()
+		if err != nil {
+			return nil, err
+		}
+		lat, lng = lat+dLat, lng+dLng
+		location = append(location, complex(float64(lng)/polylinePrecision, float64(lat)/polylinePrecision))
+	}
+	return location, nil
+}
+
+// TestPolyline encodes the reference path of the encoded polyline algorithm
+// documentation, latitude 38.5 longitude -120.2, then 40.7 -120.95 and 43.252
+// -126.453, and decodes the reference polyline back to the path
+func TestPolyline(t *testing.T) {
+	const reference = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+	path := []complex128{complex(-120.2, 38.5), complex(-120.95, 40.7), complex(-126.453, 43.252)}
+	if got := encodePolyline(path); got != reference {
+		t.Fatalf("path encoded as %q, want %q", got, reference)
+	}
+
+	decoded, err := decodePolyline(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(path) {
+		t.Fatalf("polyline decoded to %d points, want %d", len(decoded), len(path))
+	}
+	for i, z := range decoded {
+		if math.Abs(real(z)-real(path[i])) > 0.5/polylinePrecision || math.Abs(imag(z)-imag(path[i])) > 0.5/polylinePrecision {
+			t.Fatalf("point %d decoded to %v, want %v", i, z, path[i])
+		}
+	}
+	if _, err := decodePolyline(reference[:len(reference)-1]); err == nil {
+		t.Fatalf("truncated polyline decoded without an error")
+	}
+}
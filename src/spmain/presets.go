@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// presets are the curated vertex locations selectable in the Graph Options form
+// for reproducible demos.  The locations are not random, unlike a seeded graph.
+var presets = map[string][]complex128{
+	// regular pentagon on the unit circle
+	"pentagon": {
+		complex(0.000000, 1.000000),
+		complex(0.951057, 0.309017),
+		complex(0.587785, -0.809017),
+		complex(-0.587785, -0.809017),
+		complex(-0.951057, 0.309017),
+	},
+	// 5 x 5 grid with unit spacing
+	"grid5x5": gridPreset(5),
+	// two clusters of five vertices in opposite corners
+	"two-clusters": {
+		complex(1.0, 1.0), complex(1.5, 2.0), complex(2.0, 1.2), complex(2.4, 2.3), complex(1.2, 2.6),
+		complex(7.6, 7.4), complex(8.0, 8.5), complex(8.6, 7.8), complex(9.0, 8.8), complex(7.8, 9.1),
+	},
+}
+
+// gridPreset creates the locations of an n x n grid with unit spacing
+func gridPreset(n int) []complex128 {
+	location := make([]complex128, 0, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			location = append(location, complex(float64(j), float64(i)))
+		}
+	}
+	return location
+}
+
+// presetVertices sets the vertex locations from the named preset and fits the
+// endpoints around them with a margin of a tenth of their size
+func (p *PrimMST) presetVertices(name string) error {
+	location, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("preset %s does not exist", name)
+	}
+
+	p.Endpoints = fitEndpoints(location)
+
+	// Copy the locations so the preset is not modified
+	p.location = make([]complex128, len(location))
+	copy(p.location, location)
+
+	return nil
+}
+
+// fitEndpoints returns the bounds of the locations with a 10% margin on each side
+func fitEndpoints(location []complex128) *Endpoints {
+	xmin, ymin := math.MaxFloat64, math.MaxFloat64
+	xmax, ymax := -math.MaxFloat64, -math.MaxFloat64
+	for _, z := range location {
+		xmin = math.Min(xmin, real(z))
+		xmax = math.Max(xmax, real(z))
+		ymin = math.Min(ymin, imag(z))
+		ymax = math.Max(ymax, imag(z))
+	}
+	margin := math.Max(xmax-xmin, ymax-ymin) / 10
+	if margin == 0 {
+		margin = 1
+	}
+	return &Endpoints{xmin: xmin - margin, ymin: ymin - margin, xmax: xmax + margin, ymax: ymax + margin}
+}
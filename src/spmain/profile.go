@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Type to contain one vertex of the SP profile
+type ProfilePointT struct {
+	Vertex   int      `json:"vertex"`
+	Distance float64  `json:"distance"`       // cumulative distance from the source
+	Attr     *float64 `json:"attr,omitempty"` // vertex attribute, omitted if the graph has none
+	Name     string   `json:"name,omitempty"` // vertex name, omitted if the graph has none
+}
+
+// Type to contain the SP profile returned by /api/profile
+type ProfileT struct {
+	Source   int             `json:"source"`
+	Target   int             `json:"target"`
+	Distance float64         `json:"distance"`
+	Profile  []ProfilePointT `json:"profile"`
+	Polyline string          `json:"polyline"` // SP locations as an encoded polyline
+}
+
+// profile builds the cross-section of the SP found by findSP: the cumulative distance
+// and the vertex attribute at every vertex from source to target
+func (dsp *DijksraSP) profile() ([]ProfilePointT, error) {
+	path := dsp.pathVertices()
+	if path == nil {
+		return nil, fmt.Errorf("no path from vertex %d to vertex %d", dsp.source, dsp.target)
+	}
+	points := make([]ProfilePointT, len(path))
+	distance := 0.0
+	for i, v := range path {
+		if i > 0 {
+			distance += dsp.graph[path[i-1]][v]
+		}
+		points[i] = ProfilePointT{Vertex: v, Distance: distance}
+		if len(dsp.names) > 0 {
+			points[i].Name = dsp.names[v]
+		}
+		if len(dsp.attr) > 0 {
+			attr := dsp.attr[v]
+			points[i].Attr = &attr
+		}
+	}
+	return points, nil
+}
+
+// HTTP handler for /api/profile connections.  It finds the SP between the sourcevert
+// and targetvert of the saved graph and returns its distance and profile.
+func handleProfile(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	dsp := newDijkstraSP(primmst)
+	if err := dsp.findSP(r); err != nil {
+		writeAPIError(w, apiCode(err), err.Error())
+		return
+	}
+	points, err := dsp.profile()
+	if err != nil {
+		writeAPIError(w, apiNoPath, err.Error())
+		return
+	}
+
+	writeJSON(w, ProfileT{
+		Source:   dsp.source,
+		Target:   dsp.target,
+		Distance: points[len(points)-1].Distance,
+		Profile:  points,
+		Polyline: dsp.polyline(),
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestProfileErrors asks /api/profile for bad source and target vertices and a bad
+// hop order, they are input errors.  An SP search error without a code of its cause
+// is an internal error.
+func TestProfileErrors(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	forms := map[string]url.Values{
+		"no target":       {"sourcevert": {"0"}},
+		"source target":   {"sourcevert": {"1"}, "targetvert": {"1"}},
+		"out of range":    {"sourcevert": {"0"}, "targetvert": {"3"}},
+		"not a vertex":    {"sourcevert": {"0"}, "targetvert": {"two"}},
+		"bad hop order":   {"sourcevert": {"0"}, "targetvert": {"2"}, "hoporder": {"any"}},
+		"bad start":       {"sourcevert": {"0"}, "targetvert": {"2"}, "startvert": {"7"}},
+		"bad edge weight": {"sourcevert": {"0"}, "targetvert": {"2"}, "edgeweights": {"0,1"}},
+	}
+	for name, form := range forms {
+		form.Set("slot", "test-profile")
+		r := httptest.NewRequest(http.MethodPost, patternProfile, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handleProfile(w, r)
+		var apiErr APIErrorT
+		if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if w.Code != http.StatusBadRequest || apiErr.Error.Code != apiInvalidInput {
+			t.Errorf("%s answered %d %s, want %d %s", name, w.Code, apiErr.Error.Code, http.StatusBadRequest, apiInvalidInput)
+		}
+	}
+
+	unreachable := withCode(apiUnreachable, errors.New("no SP"))
+	if code := apiCode(fmt.Errorf("search: %w", unreachable)); code != apiUnreachable {
+		t.Errorf("wrapped unreachable error has code %s", code)
+	}
+	if code := apiCode(errors.New("search failed")); code != apiInternal {
+		t.Errorf("error without a code has code %s, want %s", code, apiInternal)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultQueryLogSize = 1 << 20 // bytes in the query log before it is rotated
+
+// queryLog appends one CSV record per SP query.  The mutex serializes the writers
+// since every handler goroutine shares the file.
+type queryLog struct {
+	sync.Mutex
+	file    string // CSV log file, logging is off if empty
+	maxSize int64  // the file is rotated to file.1 when it reaches this size
+}
+
+// the query log set up by the -querylog flag
+var queries = &queryLog{maxSize: defaultQueryLogSize}
+
+// queryLogHeader is the first record of a new query log
+var queryLogHeader = []string{"timestamp", "vertices", "seed", "source", "target", "distance", "hops", "algorithm"}
+
+// append writes the SP query record to the log, rotating the log if it is full
+func (ql *queryLog) append(record []string) error {
+	if len(ql.file) == 0 {
+		return nil
+	}
+	ql.Lock()
+	defer ql.Unlock()
+
+	// Keep one previous log when the size cap is reached
+	if fi, err := os.Stat(ql.file); err == nil && fi.Size() >= ql.maxSize {
+		if err := os.Rename(ql.file, ql.file+".1"); err != nil {
+			fmt.Printf("Rename file %s error: %v\n", ql.file, err)
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(ql.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("Open file %s error: %v\n", ql.file, err)
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if fi.Size() == 0 {
+		w.Write(queryLogHeader)
+	}
+	w.Write(record)
+	w.Flush()
+	return w.Error()
+}
+
+// logQuery records the SP query parameters and results in the query log.  The seed
+// is the one that generated the vertex layout of the query, empty for a graph
+// without one such as a preset or an upload.
+func (dsp *DijksraSP) logQuery(algorithm string) error {
+	distance, hops := "", ""
+	if path := dsp.pathVertices(); path != nil {
+		distance = strconv.FormatFloat(dsp.distTo[dsp.target], 'f', 6, 64)
+		hops = strconv.Itoa(len(path) - 1)
+	}
+	return queries.append([]string{
+		time.Now().Format(time.RFC3339),
+		strconv.Itoa(len(dsp.location)),
+		dsp.plot.Seed,
+		strconv.Itoa(dsp.source),
+		strconv.Itoa(dsp.target),
+		distance,
+		hops,
+		algorithm,
+	})
+}
+
+// HTTP handler for /log connections.  It shows the query log as CSV, or downloads
+// it as an attachment with download=1.
+func handleQueryLog(w http.ResponseWriter, r *http.Request) {
+	if len(queries.file) == 0 {
+		http.Error(w, "query log is off, start the server with -querylog", http.StatusNotFound)
+		return
+	}
+	queries.Lock()
+	defer queries.Unlock()
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if r.FormValue("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=query.log")
+	}
+	http.ServeFile(w, r, queries.file)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestQueryLog generates a graph of a seed and asks for its SP with the seed of the
+// form, as the page does.  The query log records the seed of that layout, not the
+// seed of the server.
+func TestQueryLog(t *testing.T) {
+	tempGraphs(t)
+	logFile := queries.file
+	defer func() { queries.file = logFile }()
+	queries.file = filepath.Join(t.TempDir(), "query.log")
+
+	graph := url.Values{"slot": {"test-querylog"}, "vertices": {"10"}, "seed": {"42"},
+		"xmin": {"0"}, "ymin": {"0"}, "xmax": {"10"}, "ymax": {"10"}}
+	handleDijkstraSP(httptest.NewRecorder(), postForm(graph))
+	sp := url.Values{"slot": {"test-querylog"}, "seed": {"42"}, "sourcevert": {"0"}, "targetvert": {"9"}}
+	handleDijkstraSP(httptest.NewRecorder(), postForm(sp))
+
+	f, err := os.Open(queries.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("query log has %d records, want the header and one query", len(records))
+	}
+	if got := records[1]; got[1] != "10" || got[2] != "42" || got[3] != "0" || got[4] != "9" {
+		t.Fatalf("query log record %q, want 10 vertices of seed 42 from 0 to 9", got)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+const defaultRenderCacheSize = 32 // rendered pages kept by the response cache
+
+// renderEntry is one rendered page of the response cache
+type renderEntry struct {
+	key  string // normalized request
+	etag string // strong ETag of the page
+	page []byte // rendered HTML
+}
+
+// renderCache keeps the most recently used rendered pages.  A page only depends on
+// the request and the saved graph, so a repeated request is served without computing
+// the MST and SP.  The mutex serializes the handler goroutines.
+type renderCache struct {
+	sync.Mutex
+	size    int                      // maximum pages, the cache is off if 0
+	order   *list.List               // pages from most to least recently used
+	entries map[string]*list.Element // pages by normalized request
+}
+
+// the response cache sized by the -rendercache flag
+var renders = &renderCache{size: defaultRenderCacheSize, order: list.New(), entries: make(map[string]*list.Element)}
+
+// renderKey returns the normalized request of the Dijkstra SP form, or false if its
+// page cannot be reproduced.  New random vertices, uploads, perturbation, demos and
+// the through fraction use the random numbers, the source cache and the query log
+// have server side state.
+// The saved graph file size and modification time are part of the key, so saving a
+// new graph invalidates the pages of the old one.
+func renderKey(r *http.Request) (string, bool) {
+	if renders.size == 0 || len(queries.file) > 0 {
+		return "", false
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return "", false
+	}
+	if r.MultipartForm != nil && len(r.MultipartForm.File) > 0 {
+		return "", false
+	}
+	if len(r.FormValue("sourcevert")) == 0 || len(r.FormValue("targetvert")) == 0 ||
+		len(r.FormValue("perturb")) > 0 || r.FormValue("demo") == "on" || r.FormValue("cachesp") == "on" ||
+		len(r.FormValue("through")) > 0 {
+		return "", false
+	}
+	file, err := slotFile(r.FormValue("slot"))
+	if err != nil {
+		return "", false
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+
+	// Empty values are the same as missing ones, Encode sorts by name
+	form := make(url.Values)
+	for name, values := range r.Form {
+		if len(values) > 0 && len(values[0]) > 0 {
+			form[name] = values
+		}
+	}
+	return fmt.Sprintf("%s %d %d %s", file, fi.Size(), fi.ModTime().UnixNano(), form.Encode()), true
+}
+
+// get returns the page of the request and marks it most recently used
+func (rc *renderCache) get(key string) (*renderEntry, bool) {
+	rc.Lock()
+	defer rc.Unlock()
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.order.MoveToFront(elem)
+	return elem.Value.(*renderEntry), true
+}
+
+// put adds the page of the request, removing the least recently used page when full
+func (rc *renderCache) put(key string, page []byte) *renderEntry {
+	sum := sha256.Sum256(page)
+	entry := &renderEntry{key: key, etag: `"` + hex.EncodeToString(sum[:16]) + `"`, page: page}
+	rc.Lock()
+	defer rc.Unlock()
+	if elem, ok := rc.entries[key]; ok {
+		elem.Value = entry
+		rc.order.MoveToFront(elem)
+		return entry
+	}
+	rc.entries[key] = rc.order.PushFront(entry)
+	for rc.order.Len() > rc.size {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*renderEntry).key)
+	}
+	return entry
+}
+
+// write sends the page with its ETag, or 304 Not Modified if the client has it
+func (entry *renderEntry) write(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", entry.etag)
+	if r.Header.Get("If-None-Match") == entry.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(entry.page)
+}
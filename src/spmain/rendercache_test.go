@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestRenderCache loads a saved graph for its MST only and then asks for an SP of it,
+// with and without the graph cache.  The MST page has no source and target, it is
+// never cached whatever the graph cache does.  The SP page is cached with an ETag and
+// a repeated request with the ETag is not modified.
+func TestRenderCache(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	graphCacheSize := savedGraphs.size
+	defer func() { savedGraphs.size = graphCacheSize }()
+	for i, size := range []int{graphCacheSize, 0} {
+		savedGraphs.size = size
+		mst := url.Values{"slot": {"test-render"}, "load": {"1"}, "units": {"km"}}
+		pages := renders.order.Len()
+		w := httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(mst))
+		if w.Code != http.StatusOK || len(w.Header().Get("ETag")) > 0 || renders.order.Len() != pages {
+			t.Errorf("graph cache %d: MST page answered %d with ETag %q, %d pages cached, want %d",
+				size, w.Code, w.Header().Get("ETag"), renders.order.Len(), pages)
+		}
+
+		// Each round asks for another SP, so the first request is not cached yet
+		sp := url.Values{"slot": {"test-render"}, "sourcevert": {"0"}, "targetvert": {[]string{"1", "2"}[i]}}
+		w = httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(sp))
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || len(etag) == 0 {
+			t.Fatalf("graph cache %d: SP page answered %d with ETag %q", size, w.Code, etag)
+		}
+		r := postForm(sp)
+		r.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		handleDijkstraSP(w, r)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("graph cache %d: repeated SP page with its ETag answered %d, want 304", size, w.Code)
+		}
+	}
+}
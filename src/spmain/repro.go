@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Type to contain the clip rectangle of a repro bundle
+type ReproClipT struct {
+	Xmin float64 `json:"xmin"`
+	Ymin float64 `json:"ymin"`
+	Xmax float64 `json:"xmax"`
+	Ymax float64 `json:"ymax"`
+}
+
+// Type to contain the exclusion zone of a repro bundle
+type ReproExclusionT struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Radius float64 `json:"radius"`
+}
+
+// Type to contain the SP result of a repro bundle, a replay must match it
+type ReproResultT struct {
+	Distance float64 `json:"distance"` // SP distance using the active edge weights
+	Hops     int     `json:"hops"`     // SP edges
+	Path     []int   `json:"path"`     // SP vertices from source to target
+}
+
+// Type to contain the reproducible test case bundle returned by /export/repro
+type ReproT struct {
+	Seed        *int64           `json:"seed,omitempty"`        // seed of the layout and the edge probability draws
+	Epsilon     float64          `json:"epsilon"`               // tolerance of the distance comparisons
+	Slot        string           `json:"slot"`                  // graph slot, empty for the default graph
+	GraphBlock  int              `json:"graphblock"`            // graph block of the vertex file
+	Metric      string           `json:"metric"`                // distance metric
+	Algorithm   string           `json:"algorithm"`             // dijkstra or contracted
+	Source      int              `json:"source"`                // SP source vertex
+	Target      int              `json:"target"`                // SP target vertex
+	EdgeWeights string           `json:"edgeweights,omitempty"` // custom edge weights "v,w,weight" lines
+	EdgesOnly   bool             `json:"edgesonly,omitempty"`   // only the weighted edges connect the vertices
+	Clip        *ReproClipT      `json:"clip,omitempty"`        // rectangle limiting the SP search
+	Exclusion   *ReproExclusionT `json:"exclusion,omitempty"`   // circle left out of the SP search
+	Bounds      [4]float64       `json:"bounds"`                // xmin, ymin, xmax, ymax of the graph
+	Vertices    [][2]float64     `json:"vertices"`              // x, y vertex locations
+	Result      ReproResultT     `json:"result"`                // SP computed by the server
+	Curl        string           `json:"curl"`                  // command replaying the SP query
+}
+
+// reproFields are the form values read by /export/repro, a replay sends them all
+var reproFields = []string{"slot", "graphblock", "metric", "seed", "obstacles", "edgeprob", "edgeweights", "edgesonly",
+	"knn", "startvert", "sourcevert", "targetvert", "hoporder", "fullgraph", "cachesp",
+	"clipxmin", "clipymin", "clipxmax", "clipymax", "excludex", "excludey", "excluderadius", "contract"}
+
+// reproCurl returns the curl command that replays the SP query with the endpoint it
+// was sent to.  The result of the replay must equal the bundle result.
+func reproCurl(pattern string, values url.Values) string {
+	return fmt.Sprintf("curl -s -X POST http://%s%s --data '%s'", addr, pattern, values.Encode())
+}
+
+// HTTP handler for /export/repro connections.  It finds the SP of the saved graph
+// and returns everything needed to reproduce it as JSON, downloaded as an attachment
+// with download=1.
+func handleRepro(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	// The seed drew the layout of a generated graph and the edges of the edge probability
+	var layoutSeed *int64
+	if str := r.FormValue("seed"); len(str) > 0 {
+		s, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			writeAPIError(w, apiInvalidInput, fmt.Sprintf("seed %q is not a whole number", str))
+			return
+		}
+		layoutSeed = &s
+	}
+
+	dsp := newDijkstraSP(primmst)
+	if err := dsp.parseClip(r); err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if err := dsp.parseExclusion(r); err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	algorithm := "dijkstra"
+	if r.FormValue("contract") == "on" {
+		algorithm = "contracted"
+		err = dsp.findSPContracted(r)
+	} else {
+		err = dsp.findSP(r)
+	}
+	if err != nil {
+		writeAPIError(w, apiCode(err), err.Error())
+		return
+	}
+	path := dsp.pathVertices()
+	if path == nil {
+		writeAPIError(w, apiNoPath, fmt.Sprintf("no path from vertex %d to vertex %d", dsp.source, dsp.target))
+		return
+	}
+
+	block, _ := formInt(r, "graphblock", 0)
+	repro := ReproT{
+		Seed:        layoutSeed,
+		Epsilon:     epsilon,
+		Slot:        r.FormValue("slot"),
+		GraphBlock:  block,
+		Metric:      primmst.metric,
+		Algorithm:   algorithm,
+		Source:      dsp.source,
+		Target:      dsp.target,
+		EdgeWeights: primmst.plot.EdgeWeights,
+		EdgesOnly:   primmst.plot.EdgesOnly == "checked",
+		Bounds:      [4]float64{primmst.xmin, primmst.ymin, primmst.xmax, primmst.ymax},
+		Vertices:    make([][2]float64, len(primmst.location)),
+		Result:      ReproResultT{Distance: dsp.distTo[dsp.target], Hops: len(path) - 1, Path: path},
+	}
+	if dsp.clip != nil {
+		repro.Clip = &ReproClipT{Xmin: dsp.clip.xmin, Ymin: dsp.clip.ymin, Xmax: dsp.clip.xmax, Ymax: dsp.clip.ymax}
+	}
+	if dsp.exclusion != nil {
+		repro.Exclusion = &ReproExclusionT{X: real(dsp.exclusion.center), Y: imag(dsp.exclusion.center), Radius: dsp.exclusion.radius}
+	}
+	for i, z := range primmst.location {
+		repro.Vertices[i] = [2]float64{real(z), imag(z)}
+	}
+
+	// The replay uses the same form values as this query
+	values := url.Values{}
+	for _, name := range reproFields {
+		if str := strings.TrimSpace(r.FormValue(name)); len(str) > 0 {
+			values.Set(name, str)
+		}
+	}
+	repro.Curl = reproCurl(r.URL.Path, values)
+
+	if r.FormValue("download") == "1" {
+		w.Header().Set("Content-Disposition", "attachment; filename=repro_"+strconv.Itoa(dsp.source)+"_"+strconv.Itoa(dsp.target)+".json")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Keep the & of the curl command readable
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(repro); err != nil {
+		fmt.Printf("Write JSON to HTTP output error: %v\n", err)
+	}
+}
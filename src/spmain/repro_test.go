@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// reproBundle posts the form to /export/repro and decodes its bundle
+func reproBundle(t *testing.T, form url.Values) ReproT {
+	r := httptest.NewRequest(http.MethodPost, patternRepro, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handleRepro(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("repro answered %d %s", w.Code, w.Body.String())
+	}
+	var repro ReproT
+	if err := json.Unmarshal(w.Body.Bytes(), &repro); err != nil {
+		t.Fatal(err)
+	}
+	return repro
+}
+
+// TestRepro exports the bundle of an SP query with a seed, a clip rectangle and an
+// exclusion zone, and replays its curl command.  The bundle has the seed of the
+// query, and the replay goes to /export/repro with every value and finds the same SP.
+func TestRepro(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-repro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, Endpoints: &bounds, file: file,
+		location: []complex128{complex(1, 1), complex(5, 5), complex(9, 9), complex(2, 8), complex(8, 2), complex(5, 1)}}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"slot": {"test-repro"}, "seed": {"42"}, "sourcevert": {"0"}, "targetvert": {"2"}, "fullgraph": {"on"},
+		"clipxmin": {"0"}, "clipymin": {"0"}, "clipxmax": {"10"}, "clipymax": {"9.5"},
+		"excludex": {"5"}, "excludey": {"5"}, "excluderadius": {"1"}}
+	repro := reproBundle(t, form)
+	if repro.Seed == nil || *repro.Seed != 42 {
+		t.Errorf("bundle seed %v, want 42", repro.Seed)
+	}
+	if repro.Clip == nil || repro.Clip.Ymax != 9.5 {
+		t.Errorf("bundle clip %+v, want y end 9.5", repro.Clip)
+	}
+	if repro.Exclusion == nil || repro.Exclusion.Radius != 1 {
+		t.Errorf("bundle exclusion %+v, want radius 1", repro.Exclusion)
+	}
+	for _, v := range repro.Result.Path {
+		if v == 1 {
+			t.Errorf("SP %v passes the excluded vertex 1", repro.Result.Path)
+		}
+	}
+
+	// The curl command posts the values to the endpoint of the query
+	data := repro.Curl[strings.Index(repro.Curl, "'")+1 : strings.LastIndex(repro.Curl, "'")]
+	if !strings.Contains(repro.Curl, addr+patternRepro+" ") {
+		t.Errorf("curl %q does not replay to %s", repro.Curl, patternRepro)
+	}
+	replay, err := url.ParseQuery(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range form {
+		if replay.Get(name) != form.Get(name) {
+			t.Errorf("replay %s is %q, want %q", name, replay.Get(name), form.Get(name))
+		}
+	}
+	again := reproBundle(t, replay)
+	if again.Result.Distance != repro.Result.Distance || len(again.Result.Path) != len(repro.Result.Path) {
+		t.Errorf("replay found %+v, want %+v", again.Result, repro.Result)
+	}
+}
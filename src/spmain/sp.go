@@ -13,31 +13,69 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"container/heap"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/cmplx"
 	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
 
 const (
-	addr                = "127.0.0.1:8080"              // http server listen address
+	defaultAddr         = "127.0.0.1:8080"              // http server listen address without the -addr flag or envAddr
+	envAddr             = "DIJKSTRASP_ADDR"             // environment variable of the listen address
 	fileDijkstraSP      = "templates/dijkstrasp.html"   // html for Dijkstra SP
 	fileGraphOptions    = "templates/graphoptions.html" // html for Graph Options
 	patternDijkstraSP   = "/dijkstrasp"                 // http handler for Dijkstra SP connections
 	patternGraphOptions = "/graphoptions"               // http handler for Graph Options
-	rows                = 300                           // #rows in grid
-	columns             = rows                          // #columns in grid
+	patternGridPoint    = "/api/gridpoint"              // http handler for grid to graph coordinates
+	patternMST          = "/api/mst"                    // http handler for the MST as JSON
+	patternVerifyMST    = "/api/verifymst"              // http handler for the MST cut property check
+	patternHistogram    = "/api/histogram"              // http handler for the edge length histogram
+	patternQueryLog     = "/log"                        // http handler for the SP query log
+	patternNearest      = "/api/nearest"                // http handler for the k nearest vertices
+	patternGraphSVG     = "/graph.svg"                  // http handler for the MST drawn as SVG
+	patternProfile      = "/api/profile"                // http handler for the SP attribute profile
+	patternRepro        = "/export/repro"               // http handler for the reproducible test case bundle
+	patternCompare      = "/compare"                    // http handler for the comparison of two saved graphs
+	patternOpenAPI      = "/api/openapi.json"           // http handler for the OpenAPI description of the API
+	patternAllPairs     = "/allpairs"                   // http handler for the all-pairs SP distances as CSV
+	patternSP           = "/api/sp"                     // http handler for the SP of a JSON request
+	patternDijkstraSVG  = "/dijkstrasp.svg"             // http handler for the MST and SP drawn as SVG
+	patternDOT          = "/export/dot"                 // http handler for the graph as GraphViz DOT
+	patternSPDebug      = "/api/spdebug"                // http handler for the SP search distances and parents
+	patternGraphs       = "/graphs"                     // http handler for the list of named graph slots
+	patternHealthz      = "/healthz"                    // http handler for the load balancer health check
 	xlabels             = 11                            // # labels on x axis
 	ylabels             = 11                            // # labels on y axis
 	fileVerts           = "vertices.csv"                // bounds and complex locations of vertices
+	fileVertsSlot       = "%s.csv"                      // vertex file of a named graph slot in dirGraphs
+	defaultUnits        = "units"                       // units label when none is given
+	deterministicSeed   = 1                             // random seed for the -deterministic flag
+	detourHintFactor    = 2.0                           // SP to straight-line distance ratio that shows the routing hint
+	defaultReadTimeout  = 10 * time.Second              // time to read a request including the uploaded body
+	defaultWriteTimeout = 30 * time.Second              // time to compute and write the response
+	defaultIdleTimeout  = 120 * time.Second             // time a keep-alive connection waits for the next request
+	minSpan             = 1e-6                          // minimum x and y range of the Euclidean graph
+	defaultMaxVertices  = 500                           // most random vertices without the -maxvertices flag
+	orientationMath     = "math"                        // y-axis increases up the grid, ymin at the bottom
+	orientationScreen   = "screen"                      // y-axis increases down the grid, ymin at the top
+	defaultEpsilon      = 1e-9                          // distances closer than this compare equal
+	hopsFewest          = "fewest"                      // equal distances prefer the path with fewer hops
+	hopsMost            = "most"                        // equal distances prefer the path with more hops
+	maxAllEdgesVertices = 200                           // vertices above which drawing all graph edges warns
 )
 
 // Edges are the vertices of the edge endpoints
@@ -51,33 +89,150 @@ type Item struct {
 	Edge             // embedded field accessed with v,w
 	index    int     // The index is used by Priority Queue update and is maintained by the heap.Interface
 	distance float64 // Edge distance between vertices
+	rank     int     // secondary key for equal distances, lower first, such as the hops from the source
 }
 
-// Priority Queue is a map of indexes and queue items and implements the heap.Interface
-// A map is used instead of a slice so that it can be easily determined if an edge is in the queue
-type PriorityQueue map[int]*Item
+// PriorityQueue is a slice of queue items in heap order and implements the
+// heap.Interface.  queued finds the item of a vertex so that it can be easily
+// determined if a vertex is in the queue; if a vertex is pushed again it has the
+// latest item.
+type PriorityQueue struct {
+	items  []*Item       // queue items in heap order
+	queued map[int]*Item // queue items by vertex w
+}
 
 // Minimum spanning tree holds the edge vertices
 type MST []*Edge
 
 // Type to contain all the HTML template actions
 type PlotT struct {
-	Grid           []string // plotting grid
-	Status         string   // status of the plot
-	Xlabel         []string // x-axis labels
-	Ylabel         []string // y-axis labels
-	Distance       string   // Prim MST total distance (all the edges in MST)
-	Vertices       string   // number of vertices
-	Xmin           string   // x minimum endpoint in Euclidean graph
-	Xmax           string   // x maximum endpoint in Euclidean graph
-	Ymin           string   // y minimum endpoint in Euclidean graph
-	Ymax           string   // y maximum endpoint in Euclidean graph
-	StartLocation  string   // Prim MST start vertex location in x,y coordinates
-	SourceLocation string   // source vertex for Dijkstra SP in x,y coordinates
-	TargetLocation string   // target or destination vertex for Dijkstra SP in x,y coordinates
-	Source         string   // source vertex for Dijkstra SP 0-Vertices-1
-	Target         string   // target vertex for Dijkstra SP 0-Vertices-1
-	DistanceSP     string   // shortest path distance (source->target)
+	Grid              []string   // plotting grid
+	Status            string     // status of the plot
+	Xlabel            []string   // x-axis labels
+	Ylabel            []string   // y-axis labels
+	Distance          string     // Prim MST total distance (all the edges in MST)
+	Vertices          string     // number of vertices
+	Xmin              string     // x minimum endpoint in Euclidean graph
+	Xmax              string     // x maximum endpoint in Euclidean graph
+	Ymin              string     // y minimum endpoint in Euclidean graph
+	Ymax              string     // y maximum endpoint in Euclidean graph
+	StartLocation     string     // Prim MST start vertex location in x,y coordinates
+	StartVert         string     // Prim MST start vertex
+	Seed              string     // seed of the random vertex layout
+	KNN               string     // neighbors of each vertex in the kNN graph, empty for the complete graph
+	Obstacles         string     // obstacle rectangles, one x1,y1,x2,y2 per line
+	EdgeProb          string     // probability of each edge in the Erdős–Rényi random graph
+	EdgeProbEdges     string     // edges kept in the Erdős–Rényi random graph
+	SourceLocation    string     // source vertex for Dijkstra SP in x,y coordinates
+	TargetLocation    string     // target or destination vertex for Dijkstra SP in x,y coordinates
+	Source            string     // source vertex for Dijkstra SP 0-Vertices-1
+	Target            string     // target vertex for Dijkstra SP 0-Vertices-1
+	DistanceSP        string     // shortest path distance (source->target)
+	EarlyExit         string     // checked if the early exit savings are computed
+	SettledEarly      string     // vertices settled before reaching the target
+	SettledFull       string     // vertices settled by a full settle, all reachable vertices
+	SettledRatio      string     // early exit settled vertices as a percent of the full settle
+	DetourFactor      string     // SP distance divided by the straight-line distance
+	Hint              string     // suggestion shown in the status when the SP is a long detour
+	LCA               string     // lowest common ancestor of source and target in the MST
+	LCASourceDistance string     // MST distance from source to the LCA
+	LCATargetDistance string     // MST distance from target to the LCA
+	LCANote           string     // explanation of the MST branches of source and target
+	HullRoute         string     // checked if the SP must pass through a convex hull vertex
+	HullVertex        string     // convex hull vertex chosen for the SP
+	HullLocation      string     // convex hull vertex location in x,y coordinates
+	HullDistance      string     // shortest path distance (source->hull->target)
+	HullExtra         string     // extra distance of the hull SP versus the unconstrained SP
+	Contract          string     // checked if degree-2 chains are contracted before the SP search
+	ContractVertices  string     // vertex count before -> after contraction
+	ContractEdges     string     // edge count before -> after contraction
+	Units             string     // units label appended to distances and locations
+	GraphBlock        string     // graph block selected from the vertex file
+	GraphBlocks       string     // number of graph blocks in the vertex file
+	HideMST           string     // checked if the MST edges are not drawn in the grid
+	AllEdges          string     // checked if every graph edge is drawn faintly beneath the MST
+	ShowLabels        string     // checked if the vertices are labeled with their index
+	VertexLabels      []LabelT   // vertex index labels positioned over the grid
+	EdgeWeights       string     // custom edge weights "v,w,weight" replacing Euclidean distances
+	ClipXmin          string     // x minimum of the rectangle limiting the SP search
+	ClipYmin          string     // y minimum of the rectangle limiting the SP search
+	ClipXmax          string     // x maximum of the rectangle limiting the SP search
+	ClipYmax          string     // y maximum of the rectangle limiting the SP search
+	ClipVertices      string     // number of vertices inside the clip rectangle
+	Leaves            string     // number of degree-1 vertices in the MST
+	ShowLeaves        string     // checked if the MST leaves are highlighted in the grid
+	Focus             string     // checked if the grid shows only the region around the SP
+	FocusBounds       string     // region around the SP shown in the grid
+	ZoomXmin          string     // x minimum of the zoom box shown in the grid
+	ZoomYmin          string     // y minimum of the zoom box shown in the grid
+	ZoomXmax          string     // x maximum of the zoom box shown in the grid
+	ZoomYmax          string     // y maximum of the zoom box shown in the grid
+	Slot              string     // graph slot, each slot has its own saved graph
+	DBGraph           string     // ID of the graph in the graph database, empty without one
+	Orientation       string     // y-axis orientation of the grid, math or screen
+	MaxTurns          string     // maximum turns of the turn limited SP
+	TurnAngle         string     // heading change in degrees counted as a turn
+	Turns             string     // turns of the turn limited SP
+	TurnDistance      string     // distance of the turn limited SP
+	Terminals         string     // comma-separated terminal vertices of the Steiner tree
+	SteinerEdges      string     // number of edges in the Steiner tree
+	SteinerDistance   string     // total distance of the Steiner tree edges
+	EdgesOnly         string     // checked if only the weighted edges connect the vertices
+	Imported          string     // summary of the imported graph
+	Metric            string     // distance metric, euclidean or toroidal
+	MetricNote        string     // description of the active non-Euclidean metric
+	Legend            []LegendT  // classes drawn in the grid and their meaning
+	Supersample       string     // drawing grid resolution factor 1, 2 or 4
+	GridSize          string     // number of rows and columns of the grid
+	DemoPairs         string     // number of random source/target pairs drawn for a demo
+	Demo              []DemoT    // random demo pairs and their SP distances
+	CacheSP           string     // checked if the full settle of the source is cached for new targets
+	SPCache           string     // hit if the SP reused the cached source distances, miss otherwise
+	Critical          string     // checked if the critical link of the SP is highlighted
+	CriticalLink      string     // endpoints of the longest SP edge
+	CriticalLength    string     // length of the longest SP edge
+	CriticalBackup    string     // backup route without the critical link and its added cost
+	Transparent       string     // checked if the grid has a transparent background for an overlay
+	EdgeColor         string     // custom MST edge color #rrggbb, default if empty
+	PathColor         string     // custom SP edge color #rrggbb, default if empty
+	VertexColor       string     // custom vertex color #rrggbb, default if empty
+	AllMetrics        string     // checked if the SP length is shown in every metric
+	MetricDistances   []MetricT  // SP length in every metric
+	Polyline          string     // SP locations as an encoded polyline for web maps
+	Perturbed         string     // vertices moved by the perturb percentage of the saved graph
+	HopOrder          string     // tie-break of equal SP distances, fewest or most hops, none if empty
+	Hops              string     // edges of the SP
+	Unreachable       string     // checked if the vertices unreachable from the source are shown
+	UnreachableCount  string     // number of vertices unreachable from the source
+	UnreachableList   string     // comma-separated vertices unreachable from the source
+	MaxPlot           string     // largest number of vertices drawn, all if empty
+	Decimated         string     // vertices drawn of the decimated plot
+	Through           string     // junction vertex of the through fraction
+	ThroughSamples    string     // source/target pairs sampled for the through fraction
+	ThroughFraction   string     // fraction of the SPs routing through the junction vertex
+	SourceName        string     // name of the SP source vertex, its index if the graph has no names
+	TargetName        string     // name of the SP target vertex, its index if the graph has no names
+	Route             string     // SP vertices from source to target by name
+	PathVertices      []string   // SP vertices from source to target by index
+	ExcludeX          string     // x center of the circular exclusion zone
+	ExcludeY          string     // y center of the circular exclusion zone
+	ExcludeRadius     string     // radius of the circular exclusion zone
+	FullGraph         string     // checked if the SP search uses every edge of the graph, not only the MST
+	TreeDistanceSP    string     // distance of the MST path when the SP uses the full graph
+	AStar             string     // checked if the SP is found with A* instead of Dijkstra
+	Bidirectional     string     // checked if the SP is found by searching from both ends
+	BellmanFord       string     // checked if the SP is found with Bellman-Ford, which allows negative weights
+	Settled           string     // vertices settled by the SP search, and by Dijkstra for A* or the bidirectional search
+	Relaxed           string     // edges relaxed by the SP search, and by Dijkstra for A* or the bidirectional search
+	Components        string     // connected components of the graph the SP search uses
+	ComponentSizes    string     // comma-separated vertices of each component, largest first
+	KPaths            string     // number of K shortest loopless paths
+	KShortest         []KPathT   // K shortest loopless paths and their distances
+	KShortestNote     string     // note if fewer than K loopless paths exist
+	view              *Endpoints // region of the Euclidean graph shown in the grid
+	torus             *Endpoints // bounds where the edges wrap in toroidal mode, nil otherwise
+	supersample       int        // drawing grid rows and columns per display grid row and column
+	gridSize          int        // number of rows and columns of the display grid, default if 0
 }
 
 // Type to hold the minimum and maximum data values of the Euclidean graph
@@ -86,14 +241,35 @@ type Endpoints struct {
 	xmax float64
 	ymin float64
 	ymax float64
+	// screenY puts ymin in the top grid row instead of the math convention ymax
+	screenY bool
+}
+
+// GraphBlock holds one graph read from the vertex file
+type GraphBlock struct {
+	*Endpoints              // Euclidean graph endpoints
+	location   []complex128 // complex point(x,y) coordinates of vertices
+	attr       []float64    // optional scalar attribute of the vertices, such as elevation
+	names      []string     // optional names of the vertices, such as city names
+	id         string       // graph ID in the graph cache, empty if it is not cached
 }
 
 // PrimMST type for Minimum Spanning Tree methods
 type PrimMST struct {
-	graph      [][]float64  // matrix of vertices and their distance from each other
+	graph      [][]float64  // matrix of vertices and their distance (edge weight) from each other
 	location   []complex128 // complex point(x,y) coordinates of vertices
 	mst        MST
-	*Endpoints // Euclidean graph endpoints
+	start      int         // vertex Prim's algorithm grows the MST from
+	file       string      // vertex file of the graph slot
+	graphID    string      // graph ID of the saved graph in the graph cache, empty for a new graph
+	metric     string      // distance metric between the vertices
+	attr       []float64   // optional scalar attribute of the vertices
+	names      []string    // optional names of the vertices
+	obstacles  []Endpoints // rectangles the edges cannot cross
+	neighbors  [][]int     // adjacency lists of the kNN graph, nil for the complete graph
+	moved      []int       // vertices moved by perturbVertices
+	shown      []bool      // vertices drawn when the plot is decimated, all if nil
+	*Endpoints             // Euclidean graph endpoints
 	plot       *PlotT
 }
 
@@ -103,82 +279,363 @@ type DijksraSP struct {
 	distTo     []float64    // distance to w from source
 	adj        [][]*Edge    // adjacency list
 	mst        MST          // reference PrimMST
+	neighbors  [][]int      // reference PrimMST kNN graph adjacency lists
+	start      int          // reference PrimMST MST start vertex
 	graph      [][]float64  // reference PrimMST
 	location   []complex128 // reference PrimMST
 	plot       *PlotT       // reference PrimMST
 	source     int          // start vertex for shortest path
 	target     int          // end vertex for shortest path
+	clip       *Endpoints   // optional rectangle limiting the SP search
+	exclusion  *Exclusion   // optional circle the SP search routes around
+	fullGraph  bool         // search every edge of the graph instead of only the MST edges
+	astar      bool         // order the search by the heuristic distance to the target
+	settled    int          // vertices removed from the priority queue by findSP
+	relaxed    int          // edges relaxed from the settled vertices by findSP
+	settledTo  []bool       // vertices removed from the priority queue by searchSP
+	critical   *Edge        // longest SP edge found by plotSP, the critical link
+	hopOrder   int          // tie-break of equal distances: 1 fewest hops, -1 most hops, 0 none
+	lengths    []float64    // SP length in each of the metrics summed by plotSP
+	metric     string       // reference PrimMST
+	attr       []float64    // reference PrimMST
+	names      []string     // reference PrimMST
 	*Endpoints              // Euclidean graph endpoints
 }
 
 // global variables for parse and execution of the html template and MST construction
 var (
 	tmplForm *template.Template
+	// tmplCompare is the html template of the graph comparison
+	tmplCompare *template.Template
+	seed        int64            // random seed of the generated graphs
+	addr        = defaultAddr    // http server listen address, set by the -addr flag or envAddr
+	epsilon     = defaultEpsilon // tolerance of the distance comparisons, set by the -epsilon flag
+	// maxVertices is the most random vertices of a graph, set by the -maxvertices flag
+	maxVertices = defaultMaxVertices
+	// infinity is the distance of an unreachable vertex or a missing edge.  Unlike
+	// math.MaxFloat64 it stays infinite when an edge distance is added to it.
+	infinity = math.Inf(1)
+	// dirGraphs is the directory of the graph slot vertex files, the tests use a
+	// temporary directory
+	dirGraphs = "graphs"
 )
 
 // init parses the html template fileS
 func init() {
 	tmplForm = template.Must(template.ParseFiles(fileDijkstraSP))
+	tmplCompare = template.Must(template.ParseFiles(fileCompare))
+}
+
+// readGraphs reads the graphs from the vertex file.  The graphs are separated by a
+// blank line or a "---" line, and each one has a bounds header line "xmin,ymin,xmax,ymax"
+// followed by the "x,y" vertex location lines.  A vertex line can add an attribute
+// and a name, "x,y,attr,name", the attribute is empty in "x,y,,name" if it has none.
+func readGraphs(r io.Reader) ([]*GraphBlock, error) {
+	graphs := make([]*GraphBlock, 0)
+	var graph *GraphBlock
+	input := bufio.NewScanner(r)
+	for input.Scan() {
+		line := strings.TrimSpace(input.Text())
+		// A separator ends the current graph
+		if len(line) == 0 || line == "---" {
+			graph = nil
+			continue
+		}
+		// Each line has comma-separated values
+		values := strings.Split(line, ",")
+
+		// The first line of a graph is the bounds header
+		if graph == nil {
+			if len(values) < 4 {
+				return nil, fmt.Errorf("graph %d bounds header %q needs xmin,ymin,xmax,ymax", len(graphs), line)
+			}
+			var bounds [4]float64
+			for i := range bounds {
+				var err error
+				if bounds[i], err = strconv.ParseFloat(values[i], 64); err != nil {
+					fmt.Printf("String %s conversion to float error: %v\n", values[i], err)
+					return nil, err
+				}
+			}
+			graph = &GraphBlock{
+				Endpoints: &Endpoints{xmin: bounds[0], ymin: bounds[1], xmax: bounds[2], ymax: bounds[3]},
+				location:  make([]complex128, 0),
+			}
+			graphs = append(graphs, graph)
+			continue
+		}
+
+		if len(values) < 2 {
+			fmt.Printf("Vertex line %q needs x,y\n", line)
+			continue
+		}
+		// An optional fourth value is the vertex name, it may contain commas
+		if len(values) > 3 {
+			if len(graph.names) != len(graph.location) {
+				return nil, fmt.Errorf("graph %d vertex %d has a name, the vertices before it do not", len(graphs)-1, len(graph.location))
+			}
+			graph.names = append(graph.names, strings.TrimSpace(strings.Join(values[3:], ",")))
+		}
+		// An optional third value is the vertex attribute
+		if len(values) > 2 && len(strings.TrimSpace(values[2])) > 0 {
+			a, err := strconv.ParseFloat(values[2], 64)
+			if err != nil {
+				fmt.Printf("String %s conversion to float error: %v\n", values[2], err)
+				return nil, err
+			}
+			if len(graph.attr) != len(graph.location) {
+				return nil, fmt.Errorf("graph %d vertex %d has an attribute, the vertices before it do not", len(graphs)-1, len(graph.location))
+			}
+			graph.attr = append(graph.attr, a)
+		}
+		x, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", values[0], err)
+			continue
+		}
+		y, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", values[1], err)
+			continue
+		}
+		graph.location = append(graph.location, complex(x, y))
+	}
+	if err := input.Err(); err != nil {
+		return nil, err
+	}
+	for i, graph := range graphs {
+		if len(graph.attr) > 0 && len(graph.attr) != len(graph.location) {
+			return nil, fmt.Errorf("graph %d has attributes on %d of %d vertices", i, len(graph.attr), len(graph.location))
+		}
+		if len(graph.names) > 0 && len(graph.names) != len(graph.location) {
+			return nil, fmt.Errorf("graph %d has names on %d of %d vertices", i, len(graph.names), len(graph.location))
+		}
+	}
+	if len(graphs) == 0 {
+		return nil, fmt.Errorf("no graphs found")
+	}
+
+	return graphs, nil
+}
+
+// slotPattern is the valid graph slot name, it becomes part of the vertex file name
+var slotPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// vertexFiles guards the vertex files of all slots.  readGraphFile holds the read lock
+// and saveVertices the write lock only while the file is read or written, and no other
+// lock is taken while holding it.  A request that reads a graph and then saves it, as
+// the perturbation does, releases the read lock first, so the generate and SP paths
+// cannot deadlock.
+var vertexFiles sync.RWMutex
+
+// slotFile returns the vertex file of the graph slot.  The empty slot is the default graph.
+func slotFile(slot string) (string, error) {
+	if len(slot) == 0 {
+		return fileVerts, nil
+	}
+	if !slotPattern.MatchString(slot) {
+		return "", fmt.Errorf("graph slot %q must be 1-32 letters, digits, - or _", slot)
+	}
+	return slotPath(slot), nil
+}
+
+// slotPath returns the vertex file of the slot in the graphs directory, the slot may
+// be a glob pattern
+func slotPath(slot string) string {
+	return filepath.Join(dirGraphs, fmt.Sprintf(fileVertsSlot, slot))
+}
+
+// readGraphFile reads the graph block from the vertex file and returns it with the
+// number of graph blocks in the file.  A graph block in the graph cache is returned
+// without reading the file, the caller must not modify it.
+func readGraphFile(file string, block int) (*GraphBlock, int, error) {
+	vertexFiles.RLock()
+	defer vertexFiles.RUnlock()
+	id, err := graphID(file, block)
+	if err == nil {
+		if entry, ok := savedGraphs.get(id); ok {
+			return entry.block, entry.blocks, nil
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Printf("Open file %s error: %v\n", file, err)
+		return nil, 0, err
+	}
+	defer f.Close()
+	graphs, err := readGraphs(f)
+	if err != nil {
+		fmt.Printf("readGraphs file %s error: %v\n", file, err)
+		return nil, 0, err
+	}
+	if block < 0 || block > len(graphs)-1 {
+		return nil, 0, fmt.Errorf("graph block %d is invalid, file has %d graph blocks", block, len(graphs))
+	}
+	if len(id) > 0 {
+		graphs[block].id = id
+		savedGraphs.put(id, graphs[block], len(graphs))
+	}
+
+	return graphs[block], len(graphs), nil
+}
+
+// formRand returns a generator seeded with the seed of the form, the seed of the
+// vertex layout, or with the deterministic seed if the graph has none such as a
+// preset.  The random draws of an SP request of the saved graph repeat with it.
+func formRand(r *http.Request) (*rand.Rand, error) {
+	var seed int64 = deterministicSeed
+	if str := r.FormValue("seed"); len(str) > 0 {
+		var err error
+		if seed, err = strconv.ParseInt(str, 10, 64); err != nil {
+			fmt.Printf("String %s conversion to int error: %v\n", str, err)
+			return nil, err
+		}
+	}
+	return rand.New(rand.NewSource(seed)), nil
 }
 
 // generateVertices creates random vertices in the complex plane
 func (p *PrimMST) generateVertices(r *http.Request) error {
 
 	// if Source and Target have values, then graph was saved and
-	// we are going to calculate the SP.
+	// we are going to calculate the SP.  load=1 shows the saved graph
+	// before the first SP query.
 	sourceVert := r.PostFormValue("sourcevert")
 	targetVert := r.PostFormValue("targetvert")
-	if len(sourceVert) > 0 && len(targetVert) > 0 {
-		f, err := os.Open(fileVerts)
-		if err != nil {
-			fmt.Printf("Open file %s error: %v\n", fileVerts, err)
-		}
-		defer f.Close()
-		input := bufio.NewScanner(f)
-		input.Scan()
-		line := input.Text()
-		// Each line has comma-separated values
-		values := strings.Split(line, ",")
-		var xmin, ymin, xmax, ymax float64
-		if xmin, err = strconv.ParseFloat(values[0], 64); err != nil {
-			fmt.Printf("String %s conversion to float error: %v\n", values[0], err)
-			return err
-		}
+	saved := len(sourceVert) > 0 && len(targetVert) > 0 || r.FormValue("load") == "1"
 
-		if ymin, err = strconv.ParseFloat(values[1], 64); err != nil {
-			fmt.Printf("String %s conversion to float error: %v\n", values[1], err)
+	// Each graph slot has its own vertex file.  A new graph without a slot gets a
+	// generated graph ID as its slot, so concurrent users do not overwrite each
+	// other's graph.  The form carries the slot to the SP requests.
+	slot := r.FormValue("slot")
+	var err error
+	if len(slot) == 0 && !saved {
+		if slot, err = newGraphID(); err != nil {
 			return err
 		}
-		if xmax, err = strconv.ParseFloat(values[2], 64); err != nil {
-			fmt.Printf("String %s conversion to float error: %v\n", values[2], err)
+		removeStaleGraphs(time.Now())
+	}
+	file, err := slotFile(slot)
+	if err != nil {
+		return err
+	}
+	p.file = file
+	p.plot.Slot = slot
+	// Keep the seed of the saved layout in the form
+	p.plot.Seed = r.FormValue("seed")
+
+	if saved {
+		// Select the graph block, the file can hold several graphs
+		block := 0
+		if str := r.PostFormValue("graphblock"); len(str) > 0 {
+			var err error
+			if block, err = strconv.Atoi(str); err != nil {
+				fmt.Printf("String %s conversion to int error: %v\n", str, err)
+				return err
+			}
+		}
+		// A graph saved in the graph database is read by its ID, the vertex file of
+		// the slot is the fallback
+		graph, err := readDBGraph(r.FormValue("dbgraph"))
+		if err != nil {
 			return err
 		}
-
-		if ymax, err = strconv.ParseFloat(values[3], 64); err != nil {
-			fmt.Printf("String %s conversion to float error: %v\n", values[3], err)
+		blocks := 1
+		if graph != nil {
+			p.plot.DBGraph = r.FormValue("dbgraph")
+		} else if graph, blocks, err = readGraphFile(p.file, block); err != nil {
 			return err
 		}
-		p.Endpoints = &Endpoints{xmin: xmin, ymin: ymin, xmax: xmax, ymax: ymax}
-
-		p.location = make([]complex128, 0)
-		for input.Scan() {
-			line := input.Text()
-			// Each line has comma-separated values
-			values := strings.Split(line, ",")
-			var x, y float64
-			if x, err = strconv.ParseFloat(values[0], 64); err != nil {
-				fmt.Printf("String %s conversion to float error: %v\n", values[0], err)
-				continue
+		p.Endpoints = graph.Endpoints
+		p.location = graph.location
+		p.attr = graph.attr
+		p.names = graph.names
+		p.graphID = graph.id
+		p.plot.GraphBlock = strconv.Itoa(block)
+		p.plot.GraphBlocks = strconv.Itoa(blocks)
+
+		// Move a percentage of the saved vertices to study the SP sensitivity
+		if str := r.PostFormValue("perturb"); len(str) > 0 {
+			percent, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				fmt.Printf("String %s conversion to float error: %v\n", str, err)
+				return err
 			}
-			if y, err = strconv.ParseFloat(values[1], 64); err != nil {
-				fmt.Printf("String %s conversion to float error: %v\n", values[1], err)
-				continue
+			// The cached graph is shared, move a copy of its vertices
+			p.location = append([]complex128(nil), p.location...)
+			p.graphID = ""
+			rng, err := formRand(r)
+			if err != nil {
+				return err
+			}
+			if err := p.perturbVertices(percent, rng); err != nil {
+				return err
 			}
-			p.location = append(p.location, complex(x, y))
+			return p.saveVertices()
 		}
 
 		return nil
 	}
+	// Load the street network from uploaded OSM nodes and ways files
+	if nodes, _, err := r.FormFile("osmnodes"); err == nil {
+		defer nodes.Close()
+		ways, _, err := r.FormFile("osmways")
+		if err != nil {
+			return fmt.Errorf("OSM import needs the ways file with the nodes file")
+		}
+		defer ways.Close()
+		weights, edges, err := p.importOSM(nodes, ways)
+		if err != nil {
+			return err
+		}
+		// Only the ways are edges, the distances are in km
+		p.metric = metricHaversine
+		p.plot.EdgeWeights = weights
+		p.plot.EdgesOnly = "checked"
+		if p.plot.Units == defaultUnits {
+			p.plot.Units = osmDefaultUnits
+		}
+		p.plot.Imported = fmt.Sprintf("OSM import: %d nodes, %d edges", len(p.location), edges)
+		return p.saveVertices()
+	}
+	// Load the vertex locations and edge weights from an uploaded GraphML file
+	if f, _, err := r.FormFile("graphml"); err == nil {
+		defer f.Close()
+		weights, err := p.importGraphML(f)
+		if err != nil {
+			return err
+		}
+		p.plot.EdgeWeights = weights
+		return p.saveVertices()
+	}
+	// Load the edge weights from an uploaded adjacency matrix, only its edges connect
+	// the vertices so the weights replace the distances of the layout
+	if f, _, err := r.FormFile("adjmatrix"); err == nil {
+		defer f.Close()
+		weights, err := p.importMatrix(f)
+		if err != nil {
+			return err
+		}
+		p.plot.EdgeWeights = weights
+		p.plot.EdgesOnly = "checked"
+		return p.saveVertices()
+	}
+	// Load the vertex locations from an uploaded coordinate CSV
+	if f, _, err := r.FormFile("vertexcsv"); err == nil {
+		defer f.Close()
+		if err := p.importCSV(f); err != nil {
+			return err
+		}
+		return p.saveVertices()
+	}
+	// Use the curated vertex locations of a preset instead of random ones
+	if preset := r.FormValue("preset"); len(preset) > 0 {
+		if err := p.presetVertices(preset); err != nil {
+			return err
+		}
+		return p.saveVertices()
+	}
+
 	// Generate V vertices and locations randomly, get from HTML form
 	// or read in from a previous graph when using a new start vertex.
 	// Insert vertex complex coordinates into locations
@@ -210,6 +667,65 @@ func (p *PrimMST) generateVertices(r *http.Request) error {
 		return err
 	}
 
+	p.Endpoints, err = newEndpoints(xmin, ymin, xmax, ymax)
+	if err != nil {
+		return err
+	}
+
+	vertices := r.FormValue("vertices")
+	verts, err := strconv.Atoi(vertices)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", vertices, err)
+		return fmt.Errorf("number of vertices %q is not a whole number", vertices)
+	}
+	if err := checkVertexCount(verts); err != nil {
+		return err
+	}
+
+	// The random layout comes from the seed of the form, or a new seed drawn from the
+	// global generator, so the effective seed regenerates the same vertices
+	seed := rand.Int63()
+	if str := r.FormValue("seed"); len(str) > 0 {
+		if seed, err = strconv.ParseInt(str, 10, 64); err != nil {
+			fmt.Printf("String %s conversion to int error: %v\n", str, err)
+			return err
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	p.plot.Seed = strconv.FormatInt(seed, 10)
+
+	// Cluster the vertices in the dark regions of an uploaded density map
+	if f, _, err := r.FormFile("densitymap"); err == nil {
+		defer f.Close()
+		if err := p.densityVertices(f, verts, rng); err != nil {
+			return err
+		}
+		return p.saveVertices()
+	}
+
+	p.randomVertices(verts, rng)
+
+	return p.saveVertices()
+}
+
+// checkVertexCount rejects a number of random vertices without an edge or too many
+// for the V x V distance matrix
+func checkVertexCount(verts int) error {
+	if verts < 2 || verts > maxVertices {
+		return fmt.Errorf("number of vertices %d must be from 2 to %d", verts, maxVertices)
+	}
+	return nil
+}
+
+// newEndpoints returns the endpoints of the Euclidean graph, swapping a start that
+// exceeds its end.  The bounds must be finite and the x and y ranges must not be
+// degenerate.
+func newEndpoints(xmin, ymin, xmax, ymax float64) (*Endpoints, error) {
+	for _, bound := range []float64{xmin, ymin, xmax, ymax} {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return nil, fmt.Errorf("x and y start and end must be finite numbers")
+		}
+	}
 	// Check if xmin < xmax and ymin < ymax and correct if necessary
 	if xmin >= xmax {
 		xmin, xmax = xmax, xmin
@@ -218,42 +734,95 @@ func (p *PrimMST) generateVertices(r *http.Request) error {
 		ymin, ymax = ymax, ymin
 	}
 
-	p.Endpoints = &Endpoints{xmin: xmin, ymin: ymin, xmax: xmax, ymax: ymax}
-
-	vertices := r.FormValue("vertices")
-	verts, err := strconv.Atoi(vertices)
-	if err != nil {
-		fmt.Printf("String %s conversion to int error: %v\n", vertices, err)
-		return err
+	ep := &Endpoints{xmin: xmin, ymin: ymin, xmax: xmax, ymax: ymax}
+	if ep.degenerate() {
+		return nil, fmt.Errorf("x and y end must exceed x and y start by at least %g", minSpan)
 	}
+	return ep, nil
+}
 
-	delx := xmax - xmin
-	dely := ymax - ymin
+// randomVertices places verts vertices uniformly at random in the endpoints
+func (p *PrimMST) randomVertices(verts int, rng *rand.Rand) {
+	delx := p.xmax - p.xmin
+	dely := p.ymax - p.ymin
 	// Generate vertices
 	p.location = make([]complex128, verts)
 	for i := 0; i < verts; i++ {
-		x := xmin + delx*rand.Float64()
-		y := ymin + dely*rand.Float64()
+		x := p.xmin + delx*rng.Float64()
+		y := p.ymin + dely*rng.Float64()
 		p.location[i] = complex(x, y)
 	}
+}
+
+// saveVertices saves the endpoints and vertex locations to the vertex file and then
+// to the graph database.
+func (p *PrimMST) saveVertices() error {
+	if err := p.saveVertexFile(); err != nil {
+		return err
+	}
+	// Keep the graph in the graph database too, outside the lock of the vertex
+	// files so a slow database does not hold up the other slots
+	p.saveDBGraph()
+	return nil
+}
 
+// saveVertexFile writes the vertex file under the lock of the vertex files.  The file
+// is written under a temporary name and renamed, so a concurrent request reading the
+// graph never sees it half written.
+func (p *PrimMST) saveVertexFile() error {
+	vertexFiles.Lock()
+	defer vertexFiles.Unlock()
+	// The graph slots are in the graphs directory
+	if err := os.MkdirAll(filepath.Dir(p.file), 0755); err != nil {
+		fmt.Printf("Create directory %s error: %v\n", filepath.Dir(p.file), err)
+		return err
+	}
 	// Save the endpoints and vertex locations to a csv file
-	f, err := os.Create(fileVerts)
+	f, err := os.CreateTemp(filepath.Dir(p.file), filepath.Base(p.file)+".*")
 	if err != nil {
-		fmt.Printf("Create file %s error: %v\n", fileVerts, err)
+		fmt.Printf("Create file %s error: %v\n", p.file, err)
 		return err
 	}
-	defer f.Close()
-	// Save the endpoints
-	fmt.Fprintf(f, "%f,%f,%f,%f\n", p.xmin, p.ymin, p.xmax, p.ymax)
-	// Save the vertex locations as x,y
-	for _, z := range p.location {
-		fmt.Fprintf(f, "%f,%f\n", real(z), imag(z))
+	// Readable like a file from os.Create, the temporary file is private
+	f.Chmod(0644)
+	p.writeVertices(f)
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
 	}
-
+	if err := os.Rename(f.Name(), p.file); err != nil {
+		fmt.Printf("Rename file %s error: %v\n", f.Name(), err)
+		os.Remove(f.Name())
+		return err
+	}
+	p.plot.GraphBlock = "0"
+	p.plot.GraphBlocks = "1"
 	return nil
 }
 
+// writeVertices writes the endpoints header and the vertex location lines read by readGraphs
+func (p *PrimMST) writeVertices(w io.Writer) {
+	// Save the endpoints
+	fmt.Fprintf(w, "%f,%f,%f,%f\n", p.xmin, p.ymin, p.xmax, p.ymax)
+	// Save the vertex locations as x,y, or x,y,attr if the vertices have attributes,
+	// followed by the name if the vertices have names
+	for i, z := range p.location {
+		if len(p.names) > 0 {
+			attr := ""
+			if len(p.attr) > 0 {
+				attr = fmt.Sprintf("%f", p.attr[i])
+			}
+			fmt.Fprintf(w, "%f,%f,%s,%s\n", real(z), imag(z), attr, p.names[i])
+			continue
+		}
+		if len(p.attr) > 0 {
+			fmt.Fprintf(w, "%f,%f,%f\n", real(z), imag(z), p.attr[i])
+			continue
+		}
+		fmt.Fprintf(w, "%f,%f\n", real(z), imag(z))
+	}
+}
+
 // findDistances find distances between vertices and insert into graph
 func (p *PrimMST) findDistances() error {
 
@@ -266,221 +835,619 @@ func (p *PrimMST) findDistances() error {
 
 	for i := 0; i < verts; i++ {
 		for j := i + 1; j < verts; j++ {
-			distance := cmplx.Abs(p.location[i] - p.location[j])
+			distance := p.distance(p.metric, p.location[i], p.location[j])
+			// An edge meeting an obstacle is not in the graph
+			if p.blocked(i, j) {
+				distance = infinity
+			}
 			p.graph[i][j] = distance
 			p.graph[j][i] = distance
 		}
 	}
 	for i := 0; i < verts; i++ {
-		p.graph[i][i] = math.MaxFloat64
+		p.graph[i][i] = infinity
+	}
+
+	return nil
+}
+
+// applyWeights replaces the Euclidean distances in graph with the custom edge weights
+// from the HTML form.  Each line of the form value is "v,w,weight" for an edge.
+func (p *PrimMST) applyWeights(r *http.Request) error {
+	edgeWeights := strings.TrimSpace(r.PostFormValue("edgeweights"))
+	// Weights imported with the graph apply when the form has none
+	if len(edgeWeights) == 0 {
+		edgeWeights = p.plot.EdgeWeights
+	}
+	verts := len(p.location)
+	// Without the other edges only the weighted edges connect the vertices
+	if r.PostFormValue("edgesonly") == "on" || p.plot.EdgesOnly == "checked" {
+		p.plot.EdgesOnly = "checked"
+		for i := 0; i < verts; i++ {
+			for j := 0; j < verts; j++ {
+				p.graph[i][j] = infinity
+			}
+		}
+	}
+	if len(edgeWeights) == 0 {
+		return nil
+	}
+	p.plot.EdgeWeights = edgeWeights
+
+	for _, line := range strings.Split(edgeWeights, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		v, w, weight, err := parseEdgeWeight(line, verts)
+		if err != nil {
+			return err
+		}
+		// Only Bellman-Ford finds the SP with negative weights
+		if weight < 0 && r.PostFormValue("bellmanford") != "on" {
+			return fmt.Errorf("edge weight %q is negative, negative weights need Bellman-Ford", line)
+		}
+		p.graph[v][w] = weight
+		p.graph[w][v] = weight
 	}
 
 	return nil
 }
 
+// parseEdgeWeight gets the vertices and weight of a "v,w,weight" line of the custom
+// edge weights of a graph of verts vertices
+func parseEdgeWeight(line string, verts int) (int, int, float64, error) {
+	// Each line has comma-separated values
+	values := strings.Split(line, ",")
+	if len(values) != 3 {
+		return 0, 0, 0, fmt.Errorf("edge weight %q must be v,w,weight", line)
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(values[0]))
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", values[0], err)
+		return 0, 0, 0, err
+	}
+	w, err := strconv.Atoi(strings.TrimSpace(values[1]))
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", values[1], err)
+		return 0, 0, 0, err
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSpace(values[2]), 64)
+	if err != nil {
+		fmt.Printf("String %s conversion to float error: %v\n", values[2], err)
+		return 0, 0, 0, err
+	}
+	if v == w || v < 0 || w < 0 || v > verts-1 || w > verts-1 {
+		return 0, 0, 0, fmt.Errorf("edge weight %q vertices are invalid", line)
+	}
+	return v, w, weight, nil
+}
+
+// newPriorityQueue returns an empty priority queue
+func newPriorityQueue() PriorityQueue {
+	return PriorityQueue{items: make([]*Item, 0), queued: make(map[int]*Item)}
+}
+
 // A PriorityQueue implements heap.Interface and holds Items
 // Len returns length of queue.
 func (pq PriorityQueue) Len() int {
-	return len(pq)
+	return len(pq.items)
 }
 
-// Less returns Item weight[i] less than Item weight[j]
+// lessDistance returns true if distance a is less than distance b by more than epsilon.
+// Near-equal distances are ties, so rounding does not choose between equal paths.
+func lessDistance(a, b float64) bool {
+	return a < b-epsilon
+}
+
+// Less returns Item weight[i] less than Item weight[j].  Ties within epsilon go to
+// the lower rank and then the lower vertex, so equal distances are always settled
+// in the same order.
 func (pq PriorityQueue) Less(i, j int) bool {
-	return pq[i].distance < pq[j].distance
+	a, b := pq.items[i], pq.items[j]
+	if lessDistance(a.distance, b.distance) {
+		return true
+	}
+	if lessDistance(b.distance, a.distance) {
+		return false
+	}
+	if a.rank != b.rank {
+		return a.rank < b.rank
+	}
+	return a.w < b.w
 }
 
 // Swap swaps Item[i] and Item[j]
 func (pq PriorityQueue) Swap(i, j int) {
-	pq[i], (pq)[j] = pq[j], pq[i]
-	pq[i].index = i
-	pq[j].index = j
+	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+	pq.items[i].index = i
+	pq.items[j].index = j
 }
 
 // Push inserts an Item in the queue
 func (pq *PriorityQueue) Push(x interface{}) {
-	n := len(*pq)
 	item := x.(*Item)
-	item.index = n
-	(*pq)[n] = item
+	item.index = len(pq.items)
+	pq.items = append(pq.items, item)
+	pq.queued[item.w] = item
 }
 
 // Pop removes an Item from the queue and returns it
 func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil
+	n := len(pq.items)
+	item := pq.items[n-1]
+	pq.items[n-1] = nil
+	pq.items = pq.items[:n-1]
 	item.index = -1
-	delete(*pq, n-1)
+	if pq.queued[item.w] == item {
+		delete(pq.queued, item.w)
+	}
 	return item
 }
 
+// find returns the queue item of vertex w if it is in the queue
+func (pq *PriorityQueue) find(w int) (*Item, bool) {
+	item, ok := pq.queued[w]
+	return item, ok
+}
+
 // update modifies the distance and value of an Item in the queue
 func (pq *PriorityQueue) update(item *Item, distance float64) {
 	item.distance = distance
 	heap.Fix(pq, item.index)
 }
 
-// findMST finds the minimum spanning tree (MST) using Prim's algorithm
+// parseStart gets the MST start vertex from the HTML form, vertex 0 if it is empty
+func (p *PrimMST) parseStart(r *http.Request) error {
+	str := r.FormValue("startvert")
+	p.start = 0
+	if len(str) == 0 {
+		p.plot.StartVert = "0"
+		return nil
+	}
+	p.plot.StartVert = str
+	v, err := parseVertex(p.names, str)
+	if err != nil {
+		fmt.Printf("start vertex parse error: %v\n", err)
+		return err
+	}
+	if v < 0 || v > len(p.location)-1 {
+		return fmt.Errorf("MST start vertex %d must be 0-%d", v, len(p.location)-1)
+	}
+	p.start = v
+	return nil
+}
+
+// edges returns the MST edges, the start vertex has no edge into the tree
+func (mst MST) edges() []*Edge {
+	edges := make([]*Edge, 0, len(mst))
+	for _, e := range mst {
+		if e != nil {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// findMST finds the minimum spanning tree (MST) using Prim's algorithm.  mst[w] is
+// the edge joining vertex w to the tree, nil for the start vertex.  A disconnected
+// graph gets a spanning forest, each further tree grown from its lowest vertex with
+// a nil edge, and an error naming the number of components.
 func (p *PrimMST) findMST() error {
 	vertices := len(p.location)
 	p.mst = make(MST, vertices)
 	marked := make([]bool, vertices)
 	distTo := make([]float64, vertices)
 	for i := range distTo {
-		distTo[i] = math.MaxFloat64
+		distTo[i] = infinity
 	}
 	// Create a priority queue, put the items in it, and establish
 	// the priority queue (heap) invariants.
-	pq := make(PriorityQueue)
+	pq := newPriorityQueue()
+
+	// find shortest distance from vertex v to w
+	relax := func(v, w int) {
+		dist := p.graph[v][w]
+		// Check if already in the MST
+		if marked[w] {
+			return
+		}
+		if lessDistance(dist, distTo[w]) {
+			// Edge to w is new best connection from MST to w
+			p.mst[w] = &Edge{v: v, w: w}
+			distTo[w] = dist
+			// Check if already in the queue and update
+			item, ok := pq.find(w)
+			// update
+			if ok {
+				pq.update(item, dist)
+			} else { // insert
+				item = &Item{Edge: Edge{v: v, w: w}, distance: dist}
+				heap.Push(&pq, item)
+			}
+		}
+	}
 
 	visit := func(v int) {
 		marked[v] = true
-		// find shortest distance from vertex v to w
-		for w, dist := range p.graph[v] {
-			// Check if already in the MST
-			if marked[w] {
-				continue
-			}
-			if dist < distTo[w] {
-				// Edge to w is new best connection from MST to w
-				p.mst[w] = &Edge{v: v, w: w}
-				distTo[w] = dist
-				// Check if already in the queue and update
-				item, ok := pq[w]
-				// update
-				if ok {
-					pq.update(item, dist)
-				} else { // insert
-					item = &Item{Edge: Edge{v: v, w: w}, distance: dist}
-					heap.Push(&pq, item)
-				}
+		// A kNN graph only has edges to the neighbors, the complete graph to every vertex
+		if p.neighbors != nil {
+			for _, w := range p.neighbors[v] {
+				relax(v, w)
 			}
+			return
+		}
+		for w := range p.graph[v] {
+			relax(v, w)
 		}
 	}
 
-	// Starting index is 0, distance is MaxFloat64, put it in the queue
-	distTo[0] = math.MaxFloat64
-	pq[0] = &Item{index: 0, distance: math.MaxFloat64, Edge: Edge{v: 0, w: 0}}
-	heap.Init(&pq)
+	// Starting index is the start vertex, distance to the tree is 0, put it in the queue.
+	// Every vertex is in the tree if the graph is connected, otherwise the next tree
+	// starts at the lowest vertex not in a tree.
+	trees, unreached := 0, -1
+	for root := p.start; root >= 0; {
+		trees++
+		distTo[root] = 0.0
+		heap.Push(&pq, &Item{distance: 0.0, Edge: Edge{v: root, w: root}})
+
+		// Loop until the queue is empty and the tree is finished
+		for pq.Len() > 0 {
+			item := heap.Pop(&pq).(*Item)
+			visit(item.w)
+		}
 
-	// Loop until the queue is empty and the MST is finished
-	for pq.Len() > 0 {
-		item := heap.Pop(&pq).(*Item)
-		visit(item.w)
+		root = -1
+		for w := 0; w < vertices; w++ {
+			if !marked[w] {
+				root = w
+				break
+			}
+		}
+		if unreached < 0 {
+			unreached = root
+		}
 	}
 
+	// The obstacles, edge weights or neighbors of the request disconnected the graph
+	if trees > 1 {
+		return withCode(apiInvalidInput, fmt.Errorf("graph is not connected, it has %d components, vertex %d is not reachable from vertex %d",
+			trees, unreached, p.start))
+	}
 	return nil
 }
 
-// plotMST draws the MST onto the grid
-func (p *PrimMST) plotMST(status []string) error {
+// toGrid converts the Euclidean graph x,y coordinates to the grid row and column
+func (ep *Endpoints) toGrid(x, y float64) (int, int) {
+	return ep.toGridSize(x, y, defaultGridSize, defaultGridSize)
+}
 
-	// Apply the parsed HTML template to plot object
-	// Construct x-axis labels, y-axis labels, status message
+// toGridSize converts the Euclidean graph x,y coordinates to the row and column
+// of a grid with nrows rows and ncols columns
+func (ep *Endpoints) toGridSize(x, y float64, nrows, ncols int) (int, int) {
+	// Calculate scale factors for x and y
+	xscale := float64(ncols-1) / (ep.xmax - ep.xmin)
+	yscale := float64(nrows-1) / (ep.ymax - ep.ymin)
 
-	var (
-		xscale   float64
-		yscale   float64
-		distance float64
-	)
-	p.plot = &PlotT{}
-	p.plot.Grid = make([]string, rows*columns)
-	p.plot.Xlabel = make([]string, xlabels)
-	p.plot.Ylabel = make([]string, ylabels)
+	row := int((ep.ymax-y)*yscale + .5)
+	if ep.screenY {
+		row = int((y-ep.ymin)*yscale + .5)
+	}
+	col := int((x-ep.xmin)*xscale + .5)
+	return row, col
+}
 
+// fromGrid converts the row and column of a grid with size rows and columns to the
+// Euclidean graph x,y coordinates.  It is the inverse of toGridSize within the
+// rounding of a grid cell.
+func (ep *Endpoints) fromGrid(row, col, size int) (float64, float64) {
 	// Calculate scale factors for x and y
-	xscale = (columns - 1) / (p.xmax - p.xmin)
-	yscale = (rows - 1) / (p.ymax - p.ymin)
+	xscale := float64(size-1) / (ep.xmax - ep.xmin)
+	yscale := float64(size-1) / (ep.ymax - ep.ymin)
 
-	// Insert the mst vertices and edges in the grid
-	// loop over the MST vertices
+	x := ep.xmin + float64(col)/xscale
+	y := ep.ymax - float64(row)/yscale
+	if ep.screenY {
+		y = ep.ymin + float64(row)/yscale
+	}
+	return x, y
+}
 
-	// color the vertices black
-	// color the edges connecting the vertices gray
-	// color the MST start vertex green
-	// create the line y = mx + b for each edge
-	// translate complex coordinates to row/col on the grid
-	// translate row/col to slice data object []string Grid
-	// CSS selectors for background-color are "vertex", "startvertexMSS", and "edge"
+// nearestVertex finds the vertex closest to the point z
+func nearestVertex(location []complex128, z complex128) int {
+	nearest := -1
+	distance := math.MaxFloat64
+	for i, loc := range location {
+		if d := cmplx.Abs(loc - z); d < distance {
+			nearest = i
+			distance = d
+		}
+	}
+	return nearest
+}
 
-	beginEP := complex(p.xmin, p.ymin)  // beginning of the Euclidean graph
-	endEP := complex(p.xmax, p.ymax)    // end of the Euclidean graph
-	lenEP := cmplx.Abs(endEP - beginEP) // length of the Euclidean graph
+// withUnits appends the units label to a distance or location
+func (plot *PlotT) withUnits(value string) string {
+	return value + " " + plot.Units
+}
 
-	for _, e := range p.mst[1:] {
+// degenerate returns true if the x or y range is too small to scale to the grid
+func (ep *Endpoints) degenerate() bool {
+	return ep.xmax-ep.xmin < minSpan || ep.ymax-ep.ymin < minSpan
+}
 
-		// Insert the edge between the vertices v, w.  Do this before marking the vertices.
-		// CSS colors the edge gray.
-		beginEdge := p.location[e.v]
-		endEdge := p.location[e.w]
-		lenEdge := cmplx.Abs(endEdge - beginEdge)
-		distance += lenEdge
-		ncells := int(columns * lenEdge / lenEP) // number of points to plot in the edge
-
-		beginX := real(beginEdge)
-		endX := real(endEdge)
-		deltaX := endX - beginX
-		stepX := deltaX / float64(ncells)
-
-		beginY := imag(beginEdge)
-		endY := imag(endEdge)
-		deltaY := endY - beginY
-		stepY := deltaY / float64(ncells)
-
-		// loop to draw the edge
-		x := beginX
-		y := beginY
-		for i := 0; i < ncells; i++ {
-			row := int((p.ymax-y)*yscale + .5)
-			col := int((x-p.xmin)*xscale + .5)
-			p.plot.Grid[row*columns+col] = "edge"
-			x += stepX
-			y += stepY
+// widen expands a degenerate x or y range to minSpan around its center
+func (ep *Endpoints) widen() *Endpoints {
+	wide := *ep
+	if wide.xmax-wide.xmin < minSpan {
+		center := (wide.xmin + wide.xmax) / 2
+		wide.xmin, wide.xmax = center-minSpan/2, center+minSpan/2
+	}
+	if wide.ymax-wide.ymin < minSpan {
+		center := (wide.ymin + wide.ymax) / 2
+		wide.ymin, wide.ymax = center-minSpan/2, center+minSpan/2
+	}
+	return &wide
+}
+
+// contains returns true if the x,y coordinates are inside the endpoints
+func (ep *Endpoints) contains(x, y float64) bool {
+	return x >= ep.xmin && x <= ep.xmax && y >= ep.ymin && y <= ep.ymax
+}
+
+// toCell converts the Euclidean graph x,y coordinates to the row and column of the
+// drawing grid, which has supersample times the rows and columns of the display grid.
+// The cell is clamped into the grid in case rounding puts it one cell outside.
+func (plot *PlotT) toCell(x, y float64) (int, int) {
+	height, width := plot.size()*plot.supersample, plot.size()*plot.supersample
+	row, col := plot.view.toGridSize(x, y, height, width)
+	return clampCell(row, height), clampCell(col, width)
+}
+
+// clampCell clamps the grid row or column i into [0, n)
+func clampCell(i, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > n-1 {
+		return n - 1
+	}
+	return i
+}
+
+// Drawing precedence of the grid classes, a class never covers one of higher priority
+const (
+	priorityEmpty  = iota // cell not drawn
+	priorityGraph         // faint graph edges beneath the MST
+	priorityGrid          // gridlines such as the clip rectangle
+	priorityMST           // MST edges
+	priorityPath          // SP edges and the other path edges
+	priorityVertex        // vertices
+	priorityMarker        // source, target and the other vertex markers
+)
+
+// classPriority is the drawing precedence of the grid classes.  The classes not
+// listed are path edges.
+var classPriority = map[string]int{
+	"":                  priorityEmpty,
+	"edgeGraph":         priorityGraph,
+	"clip":              priorityGrid,
+	"exclusion":         priorityGrid,
+	"obstacle":          priorityGrid,
+	"edge":              priorityMST,
+	"vertex":            priorityVertex,
+	"leaf":              priorityVertex,
+	"vertexMoved":       priorityVertex,
+	"vertexUnreachable": priorityVertex,
+	"vertexThrough":     priorityMarker,
+	"startvertexMSS":    priorityMarker,
+	"vertexSP1":         priorityMarker,
+	"vertexSP2":         priorityMarker,
+	"vertexHull":        priorityMarker,
+	"terminal":          priorityMarker,
+}
+
+// priority returns the drawing precedence of the grid class
+func priority(class string) int {
+	if p, ok := classPriority[class]; ok {
+		return p
+	}
+	return priorityPath
+}
+
+// paint colors the drawing grid cell unless it has a class of higher priority, so
+// the vertices and markers are never covered by the edges drawn after them.  Every
+// drawing into the grid goes through paint.
+func (plot *PlotT) paint(cell int, class string) {
+	if priority(class) >= priority(plot.Grid[cell]) {
+		plot.Grid[cell] = class
+	}
+}
+
+// setCell colors the grid cell at the Euclidean graph x,y coordinates.  Points
+// outside the plotted region are not drawn.
+func (plot *PlotT) setCell(x, y float64, class string) {
+	if !plot.view.contains(x, y) {
+		return
+	}
+	row, col := plot.toCell(x, y)
+	plot.paint(row*plot.size()*plot.supersample+col, class)
+}
+
+// setMarker marks the vertex location with a five-cell plus sign in the grid.
+// The arms are supersample cells long so the marker keeps its displayed size.
+// Arms leaving the grid at the edges and corners are not drawn.
+func (plot *PlotT) setMarker(z complex128, class string) {
+	if !plot.view.contains(real(z), imag(z)) {
+		return
+	}
+	row, col := plot.toCell(real(z), imag(z))
+	height, width := plot.size()*plot.supersample, plot.size()*plot.supersample
+	plot.paint(row*width+col, class)
+	for i := 1; i <= plot.supersample; i++ {
+		if row+i < height {
+			plot.paint((row+i)*width+col, class)
+		}
+		if row-i >= 0 {
+			plot.paint((row-i)*width+col, class)
+		}
+		if col+i < width {
+			plot.paint(row*width+col+i, class)
+		}
+		if col-i >= 0 {
+			plot.paint(row*width+col-i, class)
 		}
+	}
+}
 
-		// Mark the edge start vertex v.  CSS colors the vertex black.
-		row := int((p.ymax-beginY)*yscale + .5)
-		col := int((beginX-p.xmin)*xscale + .5)
-		p.plot.Grid[row*columns+col] = "vertex"
+// downsample reduces the supersampled drawing grid to the display grid.  Each display
+// cell gets the most frequent class of its supersample x supersample block, except a
+// vertex or marker in the block wins over the edges.  A cell that an edge only clips
+// at the corner covers less than half a row of its block and stays empty, which keeps
+// the downsampled edges thin.
+func (plot *PlotT) downsample() {
+	k := plot.supersample
+	if k <= 1 {
+		return
+	}
+	n := plot.size()
+	width := n * k
+	grid := make([]string, n*n)
+	count := make(map[string]int)
+	// rank puts the vertices and markers above the edges, the edges rank equally
+	rank := func(class string) int {
+		if p := priority(class); p >= priorityVertex {
+			return p
+		}
+		return priorityEmpty
+	}
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			best, covered := "", 0
+			for key := range count {
+				delete(count, key)
+			}
+			for i := 0; i < k; i++ {
+				for j := 0; j < k; j++ {
+					class := plot.Grid[(row*k+i)*width+col*k+j]
+					if len(class) == 0 {
+						continue
+					}
+					covered++
+					count[class]++
+					if rank(class) > rank(best) || (rank(class) == rank(best) && count[class] > count[best]) {
+						best = class
+					}
+				}
+			}
+			if covered >= k/2 || rank(best) > priorityEmpty {
+				grid[row*n+col] = best
+			}
+		}
+	}
+	plot.Grid = grid
+	plot.supersample = 1
+}
 
-		// Mark the edge end vertex w.  CSS colors the vertex black.
-		row = int((p.ymax-endY)*yscale + .5)
-		col = int((endX-p.xmin)*xscale + .5)
-		p.plot.Grid[row*columns+col] = "vertex"
+// drawEdge draws the edge between the start and end locations in the grid.  On a
+// torus an edge crossing the bounds is split into the two segments leaving the grid.
+func (plot *PlotT) drawEdge(start, end complex128, class string) {
+	if plot.torus != nil && plot.torus.isWrapped(start, end) {
+		d := plot.torus.wrap(start, end)
+		plot.drawSegment(start, start+d, class)
+		plot.drawSegment(end, end-d, class)
+		return
 	}
+	plot.drawSegment(start, end, class)
+}
 
-	// Mark the MST start vertex.  CSS colors the vertex green.
-	x := real(p.location[0])
-	y := imag(p.location[0])
-	p.plot.StartLocation = fmt.Sprintf("(%.2f, %.2f)", x, y)
-	row := int((p.ymax-y)*yscale + .5)
-	col := int((x-p.xmin)*xscale + .5)
-	p.plot.Grid[row*columns+col] = "startvertexMSS"
-	p.plot.Grid[(row+1)*columns+col] = "startvertexMSS"
-	p.plot.Grid[(row-1)*columns+col] = "startvertexMSS"
-	p.plot.Grid[row*columns+col+1] = "startvertexMSS"
-	p.plot.Grid[row*columns+col-1] = "startvertexMSS"
-
-	// Construct x-axis labels
-	incr := (p.xmax - p.xmin) / (xlabels - 1)
-	x = p.xmin
+// drawSegment draws the line segment between the start and end locations in the grid.
+// A segment leaving the plotted region is drawn up to its boundary.
+func (plot *PlotT) drawSegment(start, end complex128, class string) {
+	start, clippedEnd, inside := plot.view.clipSegment(start, end)
+	if !inside {
+		return
+	}
+	// An end on the boundary is drawn too, so the edge reaches it
+	if clippedEnd != end {
+		end = clippedEnd
+		plot.setCell(real(end), imag(end), class)
+	}
+
+	beginEP := complex(plot.view.xmin, plot.view.ymin) // beginning of the plotted region
+	endEP := complex(plot.view.xmax, plot.view.ymax)   // end of the plotted region
+	lenEP := cmplx.Abs(endEP - beginEP)                // length of the plotted region
+
+	// create the line y = mx + b for the edge.  Coincident or nearly coincident vertices
+	// still get one point, so the steps are finite.
+	ncells := int(float64(plot.size()*plot.supersample) * cmplx.Abs(end-start) / lenEP) // number of points to plot in the edge
+	if ncells < 1 {
+		ncells = 1
+	}
+	stepX := (real(end) - real(start)) / float64(ncells)
+	stepY := (imag(end) - imag(start)) / float64(ncells)
+
+	// loop to draw the edge
+	x := real(start)
+	y := imag(start)
+	for i := 0; i < ncells; i++ {
+		plot.setCell(x, y, class)
+		x += stepX
+		y += stepY
+	}
+}
+
+// plotGrid creates the grid, the axis labels, and the MST distance, endpoints and
+// vertices values.  The MST edges are drawn separately by plotMST.
+func (p *PrimMST) plotGrid() error {
+
+	// Apply the parsed HTML template to plot object
+	// Construct x-axis labels, y-axis labels, status message
+
+	// Draw into a supersampled grid, it is reduced to the display grid by downsample
+	if p.plot.supersample < 1 {
+		p.plot.supersample = 1
+	}
+	p.plot.Grid = make([]string, p.plot.size()*p.plot.size()*p.plot.supersample*p.plot.supersample)
+	p.plot.Xlabel = make([]string, xlabels)
+	// Equal bounds would make the scale factors infinite
+	if p.plot.view.degenerate() {
+		p.plot.view = p.plot.view.widen()
+	}
+	p.plot.Ylabel = make([]string, ylabels)
+
+	// Construct x-axis labels for the plotted region
+	incr := (p.plot.view.xmax - p.plot.view.xmin) / (xlabels - 1)
+	x := p.plot.view.xmin
 	// First label is empty for alignment purposes
 	for i := range p.plot.Xlabel {
 		p.plot.Xlabel[i] = fmt.Sprintf("%.2f", x)
 		x += incr
 	}
 
-	// Construct the y-axis labels
-	incr = (p.ymax - p.ymin) / (ylabels - 1)
-	y = p.ymin
+	// Construct the y-axis labels, the first label is at the bottom of the grid
+	incr = (p.plot.view.ymax - p.plot.view.ymin) / (ylabels - 1)
+	y := p.plot.view.ymin
+	if p.plot.view.screenY {
+		incr, y = -incr, p.plot.view.ymax
+	}
 	for i := range p.plot.Ylabel {
 		p.plot.Ylabel[i] = fmt.Sprintf("%.2f", y)
 		y += incr
 	}
 
-	// Distance of the MST
-	p.plot.Distance = fmt.Sprintf("%.2f", distance)
+	// Distance of the MST using the active edge weights
+	var distance float64
+	for _, e := range p.mst.edges() {
+		distance += p.graph[e.v][e.w]
+	}
+	p.plot.Distance = p.plot.withUnits(fmt.Sprintf("%.2f", distance))
+
+	// Number of MST leaves
+	p.plot.Leaves = strconv.Itoa(len(p.mstLeaves()))
+
+	// MST start vertex
+	p.plot.StartLocation = p.plot.withUnits(fmt.Sprintf("(%.2f, %.2f)", real(p.location[p.start]), imag(p.location[p.start])))
 
 	// Endpoints and Vertices
 	p.plot.Vertices = strconv.Itoa(len(p.location))
@@ -492,73 +1459,387 @@ func (p *PrimMST) plotMST(status []string) error {
 	return nil
 }
 
-// findSP constructs the shortest path from source to target
-func (dsp *DijksraSP) findSP(r *http.Request) error {
+// plotMST draws the MST onto the grid
+func (p *PrimMST) plotMST(status []string) error {
+
+	// Insert the mst vertices and edges in the grid
+	// loop over the MST vertices
+
+	// color the vertices black
+	// color the edges connecting the vertices gray
+	// color the MST start vertex green
+	// create the line y = mx + b for each edge
+	// translate complex coordinates to row/col on the grid
+	// translate row/col to slice data object []string Grid
+	// CSS selectors for background-color are "vertex", "startvertexMSS", and "edge"
+
+	for _, e := range p.mst.edges() {
+		// A decimated plot only draws the vertices it keeps and the edges between them
+		if !p.isShown(e.v) || !p.isShown(e.w) {
+			continue
+		}
+
+		// Insert the edge between the vertices v, w.  Do this before marking the vertices.
+		// CSS colors the edge gray.
+		beginEdge := p.location[e.v]
+		endEdge := p.location[e.w]
+		p.plot.drawEdge(beginEdge, endEdge, "edge")
+
+		// Mark the edge start vertex v.  CSS colors the vertex black.
+		p.plot.setCell(real(beginEdge), imag(beginEdge), "vertex")
+
+		// Mark the edge end vertex w.  CSS colors the vertex black.
+		p.plot.setCell(real(endEdge), imag(endEdge), "vertex")
+	}
+
+	// Mark the kept vertices whose MST edges were skipped
+	if p.shown != nil {
+		p.plotVertices()
+	}
+
+	// Mark the MST start vertex.  CSS colors the vertex green.
+	p.plot.setMarker(p.location[p.start], "startvertexMSS")
+
+	return nil
+}
+
+// plotGraphEdges draws every edge of the graph beneath the MST, so the edges the MST
+// and SP do not use are seen.  It returns the number of edges drawn.  CSS colors
+// the edges faintly.
+func (p *PrimMST) plotGraphEdges() int {
+	edges := 0
+	for v := range p.graph {
+		for w := v + 1; w < len(p.graph); w++ {
+			if p.graph[v][w] == infinity || !p.isShown(v) || !p.isShown(w) {
+				continue
+			}
+			p.plot.drawEdge(p.location[v], p.location[w], "edgeGraph")
+			edges++
+		}
+	}
+	return edges
+}
+
+// mstLeaves finds the degree-1 vertices of the MST
+func (p *PrimMST) mstLeaves() []int {
+	degree := make([]int, len(p.location))
+	for _, e := range p.mst.edges() {
+		degree[e.v]++
+		degree[e.w]++
+	}
+	leaves := make([]int, 0)
+	for v, d := range degree {
+		if d == 1 {
+			leaves = append(leaves, v)
+		}
+	}
+	return leaves
+}
+
+// plotLeaves marks the MST leaves onto the grid.  CSS colors the leaf vertex.
+func (p *PrimMST) plotLeaves() error {
+	for _, v := range p.mstLeaves() {
+		p.plot.setCell(real(p.location[v]), imag(p.location[v]), "leaf")
+	}
+
+	return nil
+}
+
+// plotVertices draws only the vertices onto the grid, without the MST edges
+func (p *PrimMST) plotVertices() error {
+	// Mark the vertices.  CSS colors the vertex black.
+	for v, z := range p.location {
+		if p.isShown(v) {
+			p.plot.setCell(real(z), imag(z), "vertex")
+		}
+	}
+
+	return nil
+}
+
+// parseSourceTarget gets the source and target vertices from the HTML form and validates them
+func (dsp *DijksraSP) parseSourceTarget(r *http.Request) error {
 	// need both source and target vertices for the shortest path
-	sourceVert := r.PostFormValue("sourcevert")
-	targetVert := r.PostFormValue("targetvert")
+	sourceVert := r.FormValue("sourcevert")
+	targetVert := r.FormValue("targetvert")
 	var err error
 	if len(sourceVert) == 0 || len(targetVert) == 0 {
-		return fmt.Errorf("source and/or target vertices not set")
+		return withCode(apiInvalidInput, fmt.Errorf("source and/or target vertices not set"))
 	}
-	dsp.source, err = strconv.Atoi(sourceVert)
+	// The vertices are given by index, or by name if the graph has names
+	dsp.source, err = parseVertex(dsp.names, sourceVert)
 	if err != nil {
-		fmt.Printf("source vertex Atoi error: %v\n", err)
-		return err
+		fmt.Printf("source vertex parse error: %v\n", err)
+		return withCode(apiInvalidInput, err)
 	}
-	dsp.target, err = strconv.Atoi(targetVert)
+	dsp.target, err = parseVertex(dsp.names, targetVert)
 	if err != nil {
-		fmt.Printf("target vertex Atoi error: %v\n", err)
-		return err
+		fmt.Printf("target vertex parse error: %v\n", err)
+		return withCode(apiInvalidInput, err)
 	}
 
+	return withCode(apiInvalidInput, dsp.checkSourceTarget())
+}
+
+// checkSourceTarget validates the source and target vertices
+func (dsp *DijksraSP) checkSourceTarget() error {
 	vertices := len(dsp.location)
 	if dsp.source == dsp.target || dsp.source < 0 || dsp.target < 0 ||
 		dsp.source > vertices-1 || dsp.target > vertices-1 {
 		return fmt.Errorf("source and/or target vertices are invalid")
 	}
+	if !dsp.inClip(dsp.source) || !dsp.inClip(dsp.target) {
+		return fmt.Errorf("source and/or target vertices are outside the clip rectangle")
+	}
 
-	dsp.edgeTo = make([]*Edge, vertices)
-	dsp.distTo = make([]float64, vertices)
-	for i := range dsp.distTo {
-		dsp.distTo[i] = math.MaxFloat64
+	return nil
+}
+
+// parseClip gets the optional clipping rectangle from the HTML form.  The SP search
+// is limited to the vertices inside it.
+func (dsp *DijksraSP) parseClip(r *http.Request) error {
+	names := []string{"clipxmin", "clipymin", "clipxmax", "clipymax"}
+	values := make([]float64, len(names))
+	set := 0
+	for i, name := range names {
+		str := r.PostFormValue(name)
+		if len(str) == 0 {
+			continue
+		}
+		var err error
+		if values[i], err = strconv.ParseFloat(str, 64); err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", str, err)
+			return err
+		}
+		set++
 	}
-	// Create a priority queue, put the items in it, and establish
-	// the priority queue (heap) invariants.
-	pq := make(PriorityQueue)
+	if set == 0 {
+		return nil
+	}
+	if set < len(names) {
+		return fmt.Errorf("clip rectangle needs x start, y start, x end and y end")
+	}
+
+	xmin, ymin, xmax, ymax := values[0], values[1], values[2], values[3]
+	// Check if xmin < xmax and ymin < ymax and correct if necessary
+	if xmin >= xmax {
+		xmin, xmax = xmax, xmin
+	}
+	if ymin >= ymax {
+		ymin, ymax = ymax, ymin
+	}
+	dsp.clip = &Endpoints{xmin: xmin, ymin: ymin, xmax: xmax, ymax: ymax}
+	dsp.plot.ClipXmin = fmt.Sprintf("%.2f", xmin)
+	dsp.plot.ClipYmin = fmt.Sprintf("%.2f", ymin)
+	dsp.plot.ClipXmax = fmt.Sprintf("%.2f", xmax)
+	dsp.plot.ClipYmax = fmt.Sprintf("%.2f", ymax)
+
+	// Count the vertices in scope of the search
+	inside := 0
+	for v := range dsp.location {
+		if dsp.inClip(v) {
+			inside++
+		}
+	}
+	dsp.plot.ClipVertices = strconv.Itoa(inside)
+
+	return nil
+}
+
+// inClip returns true if the vertex is inside the clipping rectangle or there is none
+func (dsp *DijksraSP) inClip(v int) bool {
+	if dsp.clip == nil {
+		return true
+	}
+	return dsp.clip.contains(real(dsp.location[v]), imag(dsp.location[v]))
+}
+
+// plotClip draws the clipping rectangle onto the grid
+func (dsp *DijksraSP) plotClip() {
+	if dsp.clip == nil {
+		return
+	}
+	// Limit the rectangle to the plotted region
+	view := dsp.plot.view
+	xmin, ymin := math.Max(dsp.clip.xmin, view.xmin), math.Max(dsp.clip.ymin, view.ymin)
+	xmax, ymax := math.Min(dsp.clip.xmax, view.xmax), math.Min(dsp.clip.ymax, view.ymax)
+	if xmin > xmax || ymin > ymax {
+		return
+	}
+	top, left := dsp.plot.toCell(xmin, ymax)
+	bottom, right := dsp.plot.toCell(xmax, ymin)
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	// CSS colors the clip rectangle border
+	width := dsp.plot.size() * dsp.plot.supersample
+	for col := left; col <= right; col++ {
+		dsp.plot.paint(top*width+col, "clip")
+		dsp.plot.paint(bottom*width+col, "clip")
+	}
+	for row := top; row <= bottom; row++ {
+		dsp.plot.paint(row*width+left, "clip")
+		dsp.plot.paint(row*width+right, "clip")
+	}
+}
 
-	// Create the adjacency list
-	dsp.adj = make([][]*Edge, vertices)
+// buildAdjacency creates the adjacency list from the MST edges, or from every edge
+// of the graph with a finite weight, or of the kNN graph, in full graph mode.  Edges with an endpoint
+// outside the clipping rectangle or crossing the exclusion zone are left out.
+func (dsp *DijksraSP) buildAdjacency() {
+	dsp.adj = make([][]*Edge, len(dsp.location))
 	for i := range dsp.adj {
 		dsp.adj[i] = make([]*Edge, 0)
 	}
-	for _, e := range dsp.mst[1:] {
+	edges := dsp.mst.edges()
+	if dsp.fullGraph {
+		edges = make([]*Edge, 0)
+		for v := range dsp.graph {
+			// A kNN graph only has edges to the neighbors
+			if dsp.neighbors != nil {
+				for _, w := range dsp.neighbors[v] {
+					if w > v {
+						edges = append(edges, &Edge{v: v, w: w})
+					}
+				}
+				continue
+			}
+			for w := v + 1; w < len(dsp.graph); w++ {
+				if dsp.graph[v][w] < infinity {
+					edges = append(edges, &Edge{v: v, w: w})
+				}
+			}
+		}
+	}
+	for _, e := range edges {
+		if !dsp.inClip(e.v) || !dsp.inClip(e.w) || dsp.excluded(e.v, e.w) {
+			continue
+		}
 		dsp.adj[e.v] = append(dsp.adj[e.v], e)
 		dsp.adj[e.w] = append(dsp.adj[e.w], e)
 	}
+}
+
+// parseHopOrder reads the hop order of the form, which breaks equal distances by the
+// fewest or the most hops from the source
+func (dsp *DijksraSP) parseHopOrder(r *http.Request) error {
+	dsp.plot.HopOrder = r.PostFormValue("hoporder")
+	switch dsp.plot.HopOrder {
+	case "":
+	case hopsFewest:
+		dsp.hopOrder = 1
+	case hopsMost:
+		dsp.hopOrder = -1
+	default:
+		return withCode(apiInvalidInput, fmt.Errorf("hop order %q must be %s or %s", dsp.plot.HopOrder, hopsFewest, hopsMost))
+	}
+	return nil
+}
+
+// findSP constructs the shortest path from the source to the target of the HTML form
+func (dsp *DijksraSP) findSP(r *http.Request) error {
+	if err := dsp.parseSourceTarget(r); err != nil {
+		return err
+	}
+	if err := dsp.parseHopOrder(r); err != nil {
+		return err
+	}
+	// Search every edge of the graph for the true SP instead of the MST path
+	if r.PostFormValue("fullgraph") == "on" {
+		dsp.plot.FullGraph = "checked"
+		dsp.fullGraph = true
+	}
+	// Source and target in different components have no SP to search for
+	dsp.buildAdjacency()
+	if err := dsp.checkComponents(); err != nil {
+		return err
+	}
+	// Reuse the distances of the source when only the target changes
+	if r.PostFormValue("cachesp") == "on" {
+		dsp.plot.CacheSP = "checked"
+		dsp.searchAdjacencyCached()
+	} else {
+		dsp.searchAdjacency()
+	}
+	if dsp.fullGraph {
+		dsp.treeDistance()
+	}
+
+	return dsp.checkExclusion()
+}
+
+// treeDistance finds the MST path distance between source and target to compare it
+// with the SP of the full graph
+func (dsp *DijksraSP) treeDistance() {
+	tree := *dsp
+	tree.fullGraph = false
+	tree.searchSP()
+	if tree.distTo[tree.target] == infinity {
+		dsp.plot.TreeDistanceSP = "not reachable"
+		return
+	}
+	dsp.plot.TreeDistanceSP = dsp.plot.withUnits(fmt.Sprintf("%.2f", tree.distTo[tree.target]))
+}
+
+// searchSP builds the adjacency list and runs Dijkstra's algorithm on it
+func (dsp *DijksraSP) searchSP() {
+	dsp.buildAdjacency()
+	dsp.searchAdjacency()
+}
+
+// searchAdjacency runs Dijkstra's algorithm on the adjacency list built by
+// buildAdjacency, from the source until the target is settled.  distTo of the target
+// stays infinity if it is not reachable.  Equal distances are broken by the hops from
+// the source in the order of hopOrder.  In A* mode the queue is ordered by the
+// distance plus the heuristic distance to the target.
+func (dsp *DijksraSP) searchAdjacency() {
+	vertices := len(dsp.location)
+	dsp.edgeTo = make([]*Edge, vertices)
+	dsp.distTo = make([]float64, vertices)
+	for i := range dsp.distTo {
+		dsp.distTo[i] = infinity
+	}
+	hopsTo := make([]int, vertices)
+	settled := make([]bool, vertices)
+	dsp.settledTo = settled
+	// Create a priority queue, put the items in it, and establish
+	// the priority queue (heap) invariants.  find gets the item of a vertex.
+	pq := newPriorityQueue()
+	h := dsp.heuristic()
 
 	relax := func(v int) {
+		// an unreached vertex has no distance to extend to its neighbors
+		if dsp.distTo[v] == infinity {
+			return
+		}
 		// find shortest distance from source to w
 		for _, e := range dsp.adj[v] {
-			// Determine v and w on the edge
+			dsp.relaxed++
+			// Determine v and w on the edge.  The edge is shared with the MST and
+			// the adjacency list, so its orientation is not changed.
 			w := e.w
 			if e.w == v {
 				w = e.v
-				e.v, e.w = e.w, e.v
 			}
 
 			newDistance := dsp.distTo[v] + dsp.graph[v][w]
-			if dsp.distTo[w] > newDistance {
-				// Edge to w is new best connection from source to w
-				dsp.edgeTo[w] = e
+			newHops := hopsTo[v] + 1
+			// An equal distance is better with the preferred hops if w is not settled
+			tie := newDistance < infinity && !lessDistance(dsp.distTo[w], newDistance) && !settled[w] &&
+				dsp.hopOrder*newHops < dsp.hopOrder*hopsTo[w]
+			if lessDistance(newDistance, dsp.distTo[w]) || tie {
+				// Edge to w is new best connection from source to w, oriented v to w
+				dsp.edgeTo[w] = &Edge{v: v, w: w}
 				dsp.distTo[w] = dsp.distTo[v] + dsp.graph[v][w]
+				hopsTo[w] = newHops
 				// Check if already in the queue and update
-				item, ok := pq[w]
+				item, ok := pq.find(w)
 				// update
 				if ok {
-					pq.update(item, newDistance)
+					item.rank = dsp.hopOrder * newHops
+					pq.update(item, newDistance+h(w))
 				} else { // insert
-					item = &Item{Edge: Edge{v: v, w: w}, distance: newDistance}
+					item = &Item{Edge: Edge{v: v, w: w}, distance: newDistance + h(w), rank: dsp.hopOrder * newHops}
 					heap.Push(&pq, item)
 				}
 			}
@@ -567,44 +1848,114 @@ func (dsp *DijksraSP) findSP(r *http.Request) error {
 
 	// Starting index is source, distance to itself is 0, put it in the queue
 	dsp.distTo[dsp.source] = 0.0
-	pq[0] = &Item{index: 0, distance: 0.0, Edge: Edge{v: dsp.source, w: dsp.source}}
-	heap.Init(&pq)
+	heap.Push(&pq, &Item{distance: 0.0, Edge: Edge{v: dsp.source, w: dsp.source}})
 
 	// Loop until the target vertex distance is found
+	dsp.settled, dsp.relaxed = 0, 0
 	for pq.Len() > 0 {
 		item := heap.Pop(&pq).(*Item)
+		dsp.settled++
+		settled[item.w] = true
 		if item.w == dsp.target {
 			// empty the priority queue to avoid memory leak
 			for pq.Len() > 0 {
 				heap.Pop(&pq)
 			}
-			return nil
+			return
 		}
 		relax(item.w)
 	}
+}
 
-	return nil
+// earlyExitSavings settles every vertex reachable from the source and reports the
+// vertices findSP settled before stopping at the target as a fraction of them
+func (dsp *DijksraSP) earlyExitSavings() {
+	distTo, _ := dsp.shortestFrom(dsp.source)
+	reachable := 0
+	for _, d := range distTo {
+		if d < infinity {
+			reachable++
+		}
+	}
+
+	dsp.plot.SettledEarly = strconv.Itoa(dsp.settled)
+	dsp.plot.SettledFull = strconv.Itoa(reachable)
+	if dsp.settled > 0 {
+		dsp.plot.SettledRatio = fmt.Sprintf("%.1f%%", 100*float64(dsp.settled)/float64(reachable))
+	}
+}
+
+// pathVertices returns the SP vertices in order from source to target, or nil if
+// the target was not reached
+func (dsp *DijksraSP) pathVertices() []int {
+	if len(dsp.edgeTo) == 0 {
+		return nil
+	}
+	path := []int{dsp.target}
+	for v := dsp.target; v != dsp.source; {
+		e := dsp.edgeTo[v]
+		if e == nil {
+			return nil
+		}
+		// the edge orientation is not fixed, so take the endpoint that is not v
+		u := e.v
+		if u == v {
+			u = e.w
+		}
+		path = append(path, u)
+		v = u
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// focusBounds finds the region around the SP vertices padded by a tenth of its
+// size and limited to the Euclidean graph endpoints
+func (dsp *DijksraSP) focusBounds() (*Endpoints, error) {
+	path := dsp.pathVertices()
+	if path == nil {
+		return nil, fmt.Errorf("distance to vertex %d not found", dsp.target)
+	}
+	focus := &Endpoints{xmin: math.MaxFloat64, ymin: math.MaxFloat64, xmax: -math.MaxFloat64, ymax: -math.MaxFloat64}
+	for _, v := range path {
+		x, y := real(dsp.location[v]), imag(dsp.location[v])
+		focus.xmin = math.Min(focus.xmin, x)
+		focus.xmax = math.Max(focus.xmax, x)
+		focus.ymin = math.Min(focus.ymin, y)
+		focus.ymax = math.Max(focus.ymax, y)
+	}
+
+	// Pad the region, a straight path needs padding from the graph size
+	pad := math.Max(focus.xmax-focus.xmin, focus.ymax-focus.ymin) / 10
+	if pad == 0 {
+		pad = math.Max(dsp.xmax-dsp.xmin, dsp.ymax-dsp.ymin) / 20
+	}
+	focus.xmin = math.Max(focus.xmin-pad, dsp.xmin)
+	focus.xmax = math.Min(focus.xmax+pad, dsp.xmax)
+	focus.ymin = math.Max(focus.ymin-pad, dsp.ymin)
+	focus.ymax = math.Min(focus.ymax+pad, dsp.ymax)
+
+	return focus, nil
 }
 
 // plotSP draws the shortest path from source to target in the grid
 func (dsp *DijksraSP) plotSP() error {
-	// check if the target was found in findSP
-	if len(dsp.distTo) == 0 || dsp.distTo[dsp.target] == math.MaxFloat64 {
-		return fmt.Errorf("distance to vertex %d not found", dsp.target)
+	// check if the target was found in findSP.  A target that was never relaxed, or
+	// a broken chain of edges, has no path to walk back to the source.
+	path := dsp.pathVertices()
+	if len(dsp.distTo) == 0 || dsp.distTo[dsp.target] == infinity || path == nil {
+		return fmt.Errorf("target vertex %d unreachable from source vertex %d", dsp.target, dsp.source)
 	}
 
 	var (
 		distance  float64 = 0.0
+		longest   float64 = -1.0
+		hops      int     = 0
 		firstEdge *Edge
 	)
-
-	// Calculate scale factors for x and y
-	xscale := (columns - 1) / (dsp.xmax - dsp.xmin)
-	yscale := (rows - 1) / (dsp.ymax - dsp.ymin)
-
-	beginEP := complex(dsp.xmin, dsp.ymin) // beginning of the Euclidean graph
-	endEP := complex(dsp.xmax, dsp.ymax)   // end of the Euclidean graph
-	lenEP := cmplx.Abs(endEP - beginEP)    // length of the Euclidean graph
+	dsp.lengths = make([]float64, len(metrics))
 
 	e := dsp.edgeTo[dsp.target]
 	// start at the target and loop until source vertex is plotted to the grid
@@ -613,40 +1964,28 @@ func (dsp *DijksraSP) plotSP() error {
 		w := e.w
 		start := dsp.location[v]
 		end := dsp.location[w]
-		x1 := real(start)
-		y1 := imag(start)
-		x2 := real(end)
-		y2 := imag(end)
-		lenEdge := cmplx.Abs(end - start)
-		distance += lenEdge
-		ncells := int(columns * lenEdge / lenEP) // number of points to plot in the edge
-
-		deltaX := x2 - x1
-		stepX := deltaX / float64(ncells)
-
-		deltaY := y2 - y1
-		stepY := deltaY / float64(ncells)
-
-		// loop to draw the edge; CSS colors the SP edge Yellow
-		x := x1
-		y := y1
-		for i := 0; i < ncells; i++ {
-			row := int((dsp.ymax-y)*yscale + .5)
-			col := int((x-dsp.xmin)*xscale + .5)
-			dsp.plot.Grid[row*columns+col] = "edgeSP"
-			x += stepX
-			y += stepY
+		distance += dsp.graph[v][w]
+		hops++
+
+		// keep the longest edge, it is the critical link of the route
+		if dsp.graph[v][w] > longest {
+			longest = dsp.graph[v][w]
+			dsp.critical = &Edge{v: v, w: w}
 		}
 
+		// measure the edge in every metric to compare the route across metrics
+		for i, metric := range metrics {
+			dsp.lengths[i] += dsp.distance(metric, start, end)
+		}
+
+		// draw the edge; CSS colors the SP edge Yellow
+		dsp.plot.drawEdge(start, end, "edgeSP")
+
 		// Mark the edge start vertex v.  CSS colors the vertex Black.
-		row := int((dsp.ymax-y1)*yscale + .5)
-		col := int((x1-dsp.xmin)*xscale + .5)
-		dsp.plot.Grid[row*columns+col] = "vertex"
+		dsp.plot.setCell(real(start), imag(start), "vertex")
 
 		// Mark the edge end vertex w.  CSS colors the vertex Black.
-		row = int((dsp.ymax-y2)*yscale + .5)
-		col = int((x2-dsp.xmin)*xscale + .5)
-		dsp.plot.Grid[row*columns+col] = "vertex"
+		dsp.plot.setCell(real(end), imag(end), "vertex")
 
 		// exit the loop if source is reached, we have the SP
 		if e.v == dsp.source {
@@ -654,11 +1993,8 @@ func (dsp *DijksraSP) plotSP() error {
 			break
 		}
 
-		// move forward to the next edge
+		// move forward to the next edge, edgeTo is oriented toward its vertex
 		e = dsp.edgeTo[v]
-		if e.w != v {
-			e.v, e.w = e.w, e.v
-		}
 	}
 
 	// Mark the end vertices of the shortest path
@@ -666,33 +2002,39 @@ func (dsp *DijksraSP) plotSP() error {
 	x := real(dsp.location[e.w])
 	y := imag(dsp.location[e.w])
 	// Mark the SP end vertex.  CSS colors the vertex Red.
-	row := int((dsp.ymax-y)*yscale + .5)
-	col := int((x-dsp.xmin)*xscale + .5)
-	dsp.plot.Grid[row*columns+col] = "vertexSP2"
-	dsp.plot.Grid[(row+1)*columns+col] = "vertexSP2"
-	dsp.plot.Grid[(row-1)*columns+col] = "vertexSP2"
-	dsp.plot.Grid[row*columns+col+1] = "vertexSP2"
-	dsp.plot.Grid[row*columns+col-1] = "vertexSP2"
-
-	dsp.plot.TargetLocation = fmt.Sprintf("(%.2f, %.2f)", x, y)
+	dsp.plot.setMarker(dsp.location[e.w], "vertexSP2")
+
+	dsp.plot.TargetLocation = dsp.plot.withUnits(fmt.Sprintf("(%.2f, %.2f)", x, y))
 	dsp.plot.Target = strconv.Itoa(e.w)
 
 	// Mark the SP start vertex.  CSS colors the vertex Blue.
 	x = real(dsp.location[firstEdge.v])
 	y = imag(dsp.location[firstEdge.v])
-	row = int((dsp.ymax-y)*yscale + .5)
-	col = int((x-dsp.xmin)*xscale + .5)
-	dsp.plot.Grid[row*columns+col] = "vertexSP1"
-	dsp.plot.Grid[(row+1)*columns+col] = "vertexSP1"
-	dsp.plot.Grid[(row-1)*columns+col] = "vertexSP1"
-	dsp.plot.Grid[row*columns+col+1] = "vertexSP1"
-	dsp.plot.Grid[row*columns+col-1] = "vertexSP1"
-
-	dsp.plot.SourceLocation = fmt.Sprintf("(%.2f, %.2f)", x, y)
+	dsp.plot.setMarker(dsp.location[firstEdge.v], "vertexSP1")
+
+	dsp.plot.SourceLocation = dsp.plot.withUnits(fmt.Sprintf("(%.2f, %.2f)", x, y))
 	dsp.plot.Source = strconv.Itoa(firstEdge.v)
+	dsp.plot.SourceName = vertexName(dsp.names, dsp.source)
+	dsp.plot.TargetName = vertexName(dsp.names, dsp.target)
+	dsp.plotRoute(path)
+	dsp.plot.PathVertices = make([]string, len(path))
+	for i, v := range path {
+		dsp.plot.PathVertices[i] = strconv.Itoa(v)
+	}
 
-	// Distance of the SP
-	dsp.plot.DistanceSP = fmt.Sprintf("%.2f", distance)
+	// Distance and hops of the SP
+	dsp.plot.DistanceSP = dsp.plot.withUnits(fmt.Sprintf("%.2f", distance))
+	dsp.plot.Hops = strconv.Itoa(hops)
+
+	// Compare the SP to the straight line, the MST can make a long detour
+	straight := dsp.distance(dsp.metric, dsp.location[dsp.source], dsp.location[dsp.target])
+	if straight > 0 {
+		factor := distance / straight
+		dsp.plot.DetourFactor = fmt.Sprintf("%.2f", factor)
+		if factor > detourHintFactor && !dsp.fullGraph {
+			dsp.plot.Hint = fmt.Sprintf("This path is constrained to the spanning tree and is %.1f times the straight-line distance", factor)
+		}
+	}
 
 	return nil
 
@@ -703,14 +2045,42 @@ func handleGraphOptions(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "templates/graphoptions.html")
 }
 
+// renderPage executes the template into a buffer.  A template error is logged and
+// answered with 500 Internal Server Error instead of half a page, and the server keeps
+// serving the other requests.  It returns false after an error.
+func renderPage(w http.ResponseWriter, tmpl *template.Template, data interface{}) ([]byte, bool) {
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		log.Printf("template %s error: %v\n", tmpl.Name(), err)
+		http.Error(w, "page rendering error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return page.Bytes(), true
+}
+
 // HTTP handler for /dijkstrasp connections
 func handleDijkstraSP(w http.ResponseWriter, r *http.Request) {
 
+	// Serve a repeated request of the saved graph from the response cache
+	key, cacheable := renderKey(r)
+	if cacheable {
+		if entry, ok := renders.get(key); ok {
+			entry.write(w, r)
+			return
+		}
+	}
+
+	// Create the plot shared by Prim MST and Dijkstra SP
+	plot := &PlotT{Units: r.PostFormValue("units")}
+	if len(plot.Units) == 0 {
+		plot.Units = defaultUnits
+	}
+
 	// Create the Prim MST instance
-	primmst := &PrimMST{}
+	primmst := &PrimMST{plot: plot}
 
 	// Create the Dijkstra SP instance
-	dijkstrasp := &DijksraSP{}
+	dijkstrasp := &DijksraSP{plot: plot}
 
 	// Accumulate error
 	status := make([]string, 0)
@@ -721,21 +2091,95 @@ func handleDijkstraSP(w http.ResponseWriter, r *http.Request) {
 	err := primmst.generateVertices(r)
 	if err != nil {
 		fmt.Printf("generateVertices error: %v\n", err)
-		status = append(status, err.Error())
+		// Without vertices there is nothing to plot, so only show the status
+		plot.Status = err.Error()
+		if page, ok := renderPage(w, tmplForm, plot); ok {
+			w.Write(page)
+		}
+		return
 	}
 
-	// Insert distances into graph
-	err = primmst.findDistances()
-	if err != nil {
-		fmt.Printf("findDistances error: %v", err)
-		status = append(status, err.Error())
+	// Measure the distances in the plane, on the torus or on the Earth.  An imported
+	// graph sets its own metric.
+	if metric := r.PostFormValue("metric"); len(metric) > 0 || len(primmst.metric) == 0 {
+		primmst.metric, err = parseMetric(metric)
+		if err != nil {
+			fmt.Printf("parseMetric error: %v\n", err)
+			status = append(status, err.Error())
+			primmst.metric = metricEuclidean
+		}
+	}
+	plot.Metric = primmst.metric
+	switch primmst.metric {
+	case metricToroidal:
+		plot.torus = primmst.Endpoints
+		plot.MetricNote = fmt.Sprintf("toroidal mode: x wraps every %.2f, y wraps every %.2f",
+			primmst.xmax-primmst.xmin, primmst.ymax-primmst.ymin)
+	case metricManhattan:
+		plot.MetricNote = "edges are drawn straight, each weighs |dx| + |dy|"
+	case metricChebyshev:
+		plot.MetricNote = "edges are drawn straight, each weighs max(|dx|, |dy|)"
 	}
 
-	// Find MST and save in PrimMST.mst
-	err = primmst.findMST()
-	if err != nil {
-		fmt.Printf("findMST error: %v\n", err)
-		status = append(status, err.Error())
+	// Reuse the distances and MST of a saved graph with the same MST options
+	mstKey, mstCacheable := primmst.mstKey(r)
+	if !mstCacheable || !primmst.cachedMST(mstKey) {
+		failed := len(status)
+		// Edges cannot cross the obstacles
+		err = primmst.parseObstacles(r)
+		if err != nil {
+			fmt.Printf("parseObstacles error: %v\n", err)
+			status = append(status, err.Error())
+		}
+
+		// Insert distances into graph
+		err = primmst.findDistances()
+		if err != nil {
+			fmt.Printf("findDistances error: %v", err)
+			status = append(status, err.Error())
+		}
+
+		// Keep each edge with the edge probability for a sparse random graph
+		err = primmst.applyEdgeProb(r)
+		if err != nil {
+			fmt.Printf("applyEdgeProb error: %v\n", err)
+			status = append(status, err.Error())
+		}
+
+		// Replace distances in graph with custom edge weights
+		err = primmst.applyWeights(r)
+		if err != nil {
+			fmt.Printf("applyWeights error: %v\n", err)
+			status = append(status, err.Error())
+		}
+
+		// Connect each vertex only to its nearest neighbors
+		err = primmst.applyKNN(r)
+		if err != nil {
+			fmt.Printf("applyKNN error: %v\n", err)
+			status = append(status, err.Error())
+		}
+
+		// Grow the MST from the start vertex of the form
+		err = primmst.parseStart(r)
+		if err != nil {
+			fmt.Printf("parseStart error: %v\n", err)
+			status = append(status, err.Error())
+		}
+
+		// Find MST and save in PrimMST.mst.  A disconnected graph is drawn as the spanning
+		// forest of its components.
+		err = primmst.findMST()
+		if err != nil {
+			fmt.Printf("findMST error: %v\n", err)
+			status = append(status, err.Error())
+		}
+		if mstCacheable && len(status) == failed {
+			primmst.cacheMST(mstKey)
+		}
+	}
+	if len(plot.Imported) > 0 {
+		status = append(status, plot.Imported)
 	}
 
 	// Assign vertex locations to dijkstrasp so it can use x,y coordinates of vertices
@@ -744,34 +2188,337 @@ func handleDijkstraSP(w http.ResponseWriter, r *http.Request) {
 	dijkstrasp.graph = primmst.graph
 	// Assign MST to dijkstrasp so it can use it to construct adj
 	dijkstrasp.mst = primmst.mst
+	dijkstrasp.start = primmst.start
+	dijkstrasp.neighbors = primmst.neighbors
 	// Assign endpoints to dijkstrasp for plotting on the grid
 	dijkstrasp.Endpoints = primmst.Endpoints
+	// Assign the metric to dijkstrasp so it can measure straight-line distances
+	dijkstrasp.metric = primmst.metric
+	// Assign the vertex attributes to dijkstrasp for the SP profile
+	dijkstrasp.attr = primmst.attr
+	// Assign the vertex names to dijkstrasp to select and show the vertices by name
+	dijkstrasp.names = primmst.names
+
+	// Limit the SP search to the clip rectangle
+	err = dijkstrasp.parseClip(r)
+	if err != nil {
+		fmt.Printf("parseClip error: %v\n", err)
+		status = append(status, err.Error())
+	}
+	// Route the SP search around the exclusion zone
+	err = dijkstrasp.parseExclusion(r)
+	if err != nil {
+		fmt.Printf("parseExclusion error: %v\n", err)
+		status = append(status, err.Error())
+	}
 
-	// Find the Shortest Path
-	err = dijkstrasp.findSP(r)
+	// Find the Shortest Path, optionally on the graph with degree-2 chains contracted.
+	// If it fails the MST is still drawn and the SP phases are skipped.
+	algorithm := "dijkstra"
+	var errSP error
+	if r.PostFormValue("contract") == "on" {
+		plot.Contract = "checked"
+		algorithm = "contracted"
+		errSP = dijkstrasp.findSPContracted(r)
+	} else if r.PostFormValue("astar") == "on" {
+		plot.AStar = "checked"
+		algorithm = "astar"
+		errSP = dijkstrasp.findSPAStar(r)
+	} else if r.PostFormValue("bidirectional") == "on" {
+		plot.Bidirectional = "checked"
+		algorithm = "bidirectional"
+		errSP = dijkstrasp.findSPBidirectional(r)
+	} else if r.PostFormValue("bellmanford") == "on" {
+		plot.BellmanFord = "checked"
+		algorithm = "bellmanford"
+		errSP = dijkstrasp.findSPBellmanFord(r)
+	} else {
+		errSP = dijkstrasp.findSP(r)
+	}
+	// A loaded graph has no SP query yet, it is not an error
+	loaded := r.FormValue("load") == "1" && len(r.PostFormValue("sourcevert")) == 0
+	if errSP != nil && !loaded {
+		fmt.Printf("findSP error: %v\n", errSP)
+		status = append(status, errSP.Error())
+	} else if errSP == nil {
+		// Work done by the search, A* and the bidirectional search report Dijkstra's too
+		if len(plot.Settled) == 0 {
+			plot.Settled = strconv.Itoa(dijkstrasp.settled)
+			plot.Relaxed = strconv.Itoa(dijkstrasp.relaxed)
+		}
+		// Record the query in the experiment log
+		if err := dijkstrasp.logQuery(algorithm); err != nil {
+			fmt.Printf("logQuery error: %v\n", err)
+			status = append(status, err.Error())
+		}
+		// Explain the MST path by the branches that source and target share
+		if err := dijkstrasp.findLCA(); err != nil {
+			fmt.Printf("findLCA error: %v\n", err)
+			status = append(status, err.Error())
+		}
+		// Compare the vertices settled before reaching the target to a full settle
+		if r.PostFormValue("earlyexit") == "on" {
+			plot.EarlyExit = "checked"
+			dijkstrasp.earlyExitSavings()
+		}
+	}
+
+	// Show the whole Euclidean graph in the grid, the zoom box, or only the region
+	// around the SP.  The zoom box takes precedence over the focus.
+	plot.view = primmst.Endpoints
+	zoom, err := primmst.parseZoom(r)
 	if err != nil {
-		fmt.Printf("findSP error: %v\n", err)
+		fmt.Printf("parseZoom error: %v\n", err)
+		status = append(status, err.Error())
+	} else if zoom != nil {
+		plot.view = zoom
+	}
+	if r.PostFormValue("focus") == "on" {
+		plot.Focus = "checked"
+	}
+	if len(plot.Focus) > 0 && zoom == nil && errSP == nil {
+		focus, err := dijkstrasp.focusBounds()
+		if err != nil {
+			fmt.Printf("focusBounds error: %v\n", err)
+			status = append(status, err.Error())
+		} else {
+			plot.view = focus
+			plot.FocusBounds = fmt.Sprintf("(%.2f, %.2f) - (%.2f, %.2f)", focus.xmin, focus.ymin, focus.xmax, focus.ymax)
+		}
+	}
+
+	// Orient the y-axis with ymin at the bottom (math) or the top (screen) of the grid
+	plot.Orientation = r.PostFormValue("orientation")
+	if plot.Orientation == orientationScreen {
+		view := *plot.view
+		view.screenY = true
+		plot.view = &view
+	} else {
+		plot.Orientation = orientationMath
+	}
+
+	// Draw on a transparent background and with custom colors for an overlay
+	if err := plot.parseOverlay(r); err != nil {
+		fmt.Printf("parseOverlay error: %v\n", err)
+		status = append(status, err.Error())
+	}
+
+	// Draw at 1x, 2x or 4x resolution and reduce to the display grid to smooth the edges
+	plot.Supersample = r.PostFormValue("supersample")
+	switch plot.Supersample {
+	case "", "1":
+		plot.Supersample = "1"
+		plot.supersample = 1
+	case "2", "4":
+		plot.supersample, _ = strconv.Atoi(plot.Supersample)
+	default:
+		status = append(status, fmt.Sprintf("supersample %q must be 1, 2 or 4", plot.Supersample))
+		plot.Supersample = "1"
+		plot.supersample = 1
+	}
+
+	// Draw into a grid of gridsize rows and columns, 300 if empty
+	if err := plot.parseGridSize(r); err != nil {
+		fmt.Printf("parseGridSize error: %v\n", err)
 		status = append(status, err.Error())
 	}
 
-	// Draw MST into 300 x 300 cell 2px grid
 	// Construct x-axis labels, y-axis labels, status message
-	err = primmst.plotMST(status)
+	err = primmst.plotGrid()
 	if err != nil {
-		fmt.Printf("plotMST error: %v\n", err)
+		fmt.Printf("plotGrid error: %v\n", err)
 		status = append(status, err.Error())
 	}
 
-	// Assign plot to dijkstrasp
-	dijkstrasp.plot = primmst.plot
+	// Draw only a subset of the vertices of a large graph, the SP is found on all of them
+	if err := primmst.parseMaxPlot(r); err != nil {
+		fmt.Printf("parseMaxPlot error: %v\n", err)
+		status = append(status, err.Error())
+	}
+
+	// Draw every graph edge faintly beneath the MST, a complete graph of many vertices
+	// has so many edges that they fill the grid
+	if r.PostFormValue("alledges") == "on" {
+		plot.AllEdges = "checked"
+		if edges := primmst.plotGraphEdges(); len(primmst.location) > maxAllEdgesVertices {
+			status = append(status, fmt.Sprintf("%d graph edges of %d vertices are drawn, they may hide the MST", edges, len(primmst.location)))
+		}
+	}
+
+	// Draw MST into 300 x 300 cell 2px grid, or only its vertices if the MST is hidden
+	if r.PostFormValue("hidemst") == "on" {
+		plot.HideMST = "checked"
+		err = primmst.plotVertices()
+		if err != nil {
+			fmt.Printf("plotVertices error: %v\n", err)
+			status = append(status, err.Error())
+		}
+	} else {
+		err = primmst.plotMST(status)
+		if err != nil {
+			fmt.Printf("plotMST error: %v\n", err)
+			status = append(status, err.Error())
+		}
+	}
+
+	// Mark the vertices moved by the perturbation
+	primmst.plotMoved()
+
+	// Highlight the MST leaves
+	if r.PostFormValue("showleaves") == "on" {
+		plot.ShowLeaves = "checked"
+		err = primmst.plotLeaves()
+		if err != nil {
+			fmt.Printf("plotLeaves error: %v\n", err)
+			status = append(status, err.Error())
+		}
+	}
+
+	// Draw the obstacles, the clip rectangle and the exclusion zone
+	primmst.plotObstacles()
+	dijkstrasp.plotClip()
+	dijkstrasp.plotExclusion()
 
 	// Draw SP into 300 x 300 cell 2px grid
-	err = dijkstrasp.plotSP()
-	if err != nil {
-		fmt.Printf("plotSP error: %v\n", err)
-		status = append(status, err.Error())
+	if errSP == nil {
+		errSP = dijkstrasp.plotSP()
+		if errSP != nil {
+			fmt.Printf("plotSP error: %v\n", errSP)
+			status = append(status, errSP.Error())
+		} else {
+			plot.Polyline = dijkstrasp.polyline()
+			if len(plot.Hint) > 0 {
+				status = append(status, plot.Hint)
+			}
+		}
+	}
+
+	// Show the vertices the source cannot reach
+	if r.PostFormValue("unreachable") == "on" {
+		plot.Unreachable = "checked"
+		if errSP == nil {
+			dijkstrasp.plotUnreachable()
+		}
+	}
+
+	// Show the SP length measured in every metric side by side
+	if r.PostFormValue("allmetrics") == "on" {
+		plot.AllMetrics = "checked"
+		if errSP == nil {
+			dijkstrasp.reportMetrics()
+		}
+	}
+
+	// Highlight the critical link of the SP and its backup route
+	if r.PostFormValue("critical") == "on" {
+		plot.Critical = "checked"
+		if errSP == nil {
+			if err := dijkstrasp.plotCritical(); err != nil {
+				fmt.Printf("plotCritical error: %v\n", err)
+				status = append(status, err.Error())
+			}
+		}
+	}
+
+	// Route the SP through a convex hull vertex if requested
+	if r.PostFormValue("hullroute") == "on" {
+		dijkstrasp.plot.HullRoute = "checked"
+		if errSP == nil {
+			err = dijkstrasp.findSPViaHull(primmst.convexHull())
+			if err != nil {
+				fmt.Printf("findSPViaHull error: %v\n", err)
+				status = append(status, err.Error())
+			}
+		}
+	}
+
+	// Find the K shortest loopless paths between source and target
+	if len(r.PostFormValue("k")) > 0 {
+		plot.KPaths = r.PostFormValue("k")
+		if errSP == nil {
+			k, err := dijkstrasp.parseKPaths(r)
+			if err == nil {
+				err = dijkstrasp.findKPaths(k)
+			}
+			if err != nil {
+				fmt.Printf("findKPaths error: %v\n", err)
+				status = append(status, err.Error())
+			}
+		}
+	}
+
+	// Find the SP with a limited number of turns
+	if len(r.PostFormValue("maxturns")) > 0 && len(dijkstrasp.adj) > 0 {
+		err := dijkstrasp.plotMaxTurns(r)
+		if err != nil {
+			fmt.Printf("plotMaxTurns error: %v\n", err)
+			status = append(status, err.Error())
+		}
+	}
+
+	// Connect the terminal vertices with an approximate Steiner tree
+	if terminals := r.PostFormValue("terminals"); len(terminals) > 0 {
+		plot.Terminals = terminals
+		t, err := dijkstrasp.parseTerminals(r)
+		if err == nil {
+			err = dijkstrasp.findSteiner(t)
+		}
+		if err != nil {
+			fmt.Printf("findSteiner error: %v\n", err)
+			status = append(status, err.Error())
+		}
 	}
 
+	// Surprise me: draw random source/target pairs of the saved graph
+	if r.PostFormValue("demo") == "on" {
+		n, err := dijkstrasp.parseDemoPairs(r)
+		var rng *rand.Rand
+		if err == nil {
+			rng, err = formRand(r)
+		}
+		if err == nil {
+			err = dijkstrasp.findDemo(n, rng)
+		}
+		if err != nil {
+			fmt.Printf("findDemo error: %v\n", err)
+			status = append(status, err.Error())
+		}
+	} else {
+		plot.DemoPairs = r.PostFormValue("demopairs")
+	}
+
+	// Fraction of the SPs routing through a junction vertex
+	if len(r.PostFormValue("through")) > 0 {
+		plot.Through = r.PostFormValue("through")
+		plot.ThroughSamples = r.PostFormValue("throughsamples")
+		if errSP == nil {
+			junction, samples, err := dijkstrasp.parseThrough(r)
+			var rng *rand.Rand
+			if err == nil {
+				rng, err = formRand(r)
+			}
+			if err == nil {
+				err = dijkstrasp.findThrough(junction, samples, rng)
+			}
+			if err != nil {
+				fmt.Printf("findThrough error: %v\n", err)
+				status = append(status, err.Error())
+			}
+		}
+	}
+
+	// Reduce the supersampled drawing grid to the display grid
+	plot.downsample()
+
+	// Label the vertices with their index next to their marker
+	if r.PostFormValue("vertexlabels") == "on" {
+		plot.ShowLabels = "checked"
+		primmst.plotVertexLabels()
+	}
+
+	// Explain the classes drawn in the grid
+	plot.plotLegend()
+
 	// Status
 	if len(status) > 0 {
 		dijkstrasp.plot.Status = strings.Join(status, ", ")
@@ -779,18 +2526,131 @@ func handleDijkstraSP(w http.ResponseWriter, r *http.Request) {
 		dijkstrasp.plot.Status = "Enter Source and Target Vertices (0-V-1) for another SP"
 	}
 
-	// Write to HTTP using template and grid
-	if err := tmplForm.Execute(w, primmst.plot); err != nil {
-		log.Fatalf("Write to HTTP output using template with grid error: %v\n", err)
+	// Write to HTTP using template and grid, keeping the page in the response cache
+	page, ok := renderPage(w, tmplForm, primmst.plot)
+	if !ok {
+		return
+	}
+	if cacheable {
+		renders.put(key, page).write(w, r)
+		return
 	}
+	w.Write(page)
 }
 
 // main sets up the http handlers, listens, and serves http clients
 func main() {
-	rand.Seed(time.Now().Unix())
+	deterministic := flag.Bool("deterministic", false, "seed the random graphs with a fixed seed for reproducible tests")
+	readTimeout := flag.Duration("readtimeout", defaultReadTimeout, "maximum duration to read a request")
+	writeTimeout := flag.Duration("writetimeout", defaultWriteTimeout, "maximum duration to write a response")
+	idleTimeout := flag.Duration("idletimeout", defaultIdleTimeout, "maximum duration to keep an idle connection open")
+	queryLogFile := flag.String("querylog", "", "append each SP query to this CSV file, for example query.log")
+	queryLogSize := flag.Int64("querylogsize", defaultQueryLogSize, "bytes in the query log before it is rotated to a .1 file")
+	flag.Float64Var(&epsilon, "epsilon", defaultEpsilon, "distances closer than this are equal when comparing paths")
+	flag.IntVar(&maxVertices, "maxvertices", defaultMaxVertices, "most random vertices of a generated graph, the distance matrix is V x V")
+	flag.IntVar(&renders.size, "rendercache", defaultRenderCacheSize, "rendered pages kept for repeated requests, 0 turns the cache off")
+	flag.IntVar(&savedGraphs.size, "graphcache", defaultGraphCacheSize, "parsed graphs and their MST kept for repeated requests, 0 turns the cache off")
+	dbSource := flag.String("db", "", "graph database data source, for example graphs.db, the vertex files are used without it")
+	dbDriver := flag.String("dbdriver", defaultDBDriver, "database/sql driver of the graph database")
+	flag.StringVar(&addr, "addr", "", "http server listen address, default $"+envAddr+" or "+defaultAddr)
+	cli := flag.Bool("cli", false, "print the SP of a random graph to stdout and exit instead of serving")
+	var cliReq SPRequestT
+	flag.IntVar(&cliReq.Vertices, "vertices", 100, "with -cli, number of random vertices")
+	flag.Float64Var(&cliReq.Xmin, "xmin", 0, "with -cli, x start of the Euclidean graph")
+	flag.Float64Var(&cliReq.Ymin, "ymin", 0, "with -cli, y start of the Euclidean graph")
+	flag.Float64Var(&cliReq.Xmax, "xmax", 100, "with -cli, x end of the Euclidean graph")
+	flag.Float64Var(&cliReq.Ymax, "ymax", 100, "with -cli, y end of the Euclidean graph")
+	flag.IntVar(&cliReq.Source, "source", 0, "with -cli, SP source vertex")
+	flag.IntVar(&cliReq.Target, "target", 1, "with -cli, SP target vertex")
+	cliSeed := flag.Int64("seed", -1, "with -cli, seed of the vertex layout, random if negative")
+	flag.Parse()
+	if epsilon < 0 {
+		log.Fatalf("epsilon %g must not be negative\n", epsilon)
+	}
+	// The flag takes precedence over the environment
+	if len(addr) == 0 {
+		addr = os.Getenv(envAddr)
+	}
+	if len(addr) == 0 {
+		addr = defaultAddr
+	}
+	if maxVertices < 2 {
+		log.Fatalf("maxvertices %d must be at least 2\n", maxVertices)
+	}
+	if renders.size < 0 {
+		log.Fatalf("rendercache %d must not be negative\n", renders.size)
+	}
+	if savedGraphs.size < 0 {
+		log.Fatalf("graphcache %d must not be negative\n", savedGraphs.size)
+	}
+	// Print the SP of the flags instead of serving
+	if *cli {
+		if *cliSeed >= 0 {
+			cliReq.Seed = cliSeed
+		}
+		if err := runCLI(os.Stdout, cliReq); err != nil {
+			log.Fatalf("cli error: %v\n", err)
+		}
+		return
+	}
+	if *deterministic {
+		seed = deterministicSeed
+		fmt.Printf("Deterministic mode, random seed is %d.\n", deterministicSeed)
+	} else {
+		seed = time.Now().Unix()
+	}
+	rand.Seed(seed)
+	queries.file = *queryLogFile
+	queries.maxSize = *queryLogSize
+	// Save the graphs in the database too and read them by their ID, falling back to
+	// the vertex files if it cannot be opened
+	if len(*dbSource) > 0 {
+		db, err := openGraphDB(*dbDriver, *dbSource)
+		if err != nil {
+			fmt.Printf("graph database %s error: %v, using the vertex files\n", *dbSource, err)
+		} else {
+			graphDB = db
+			defer graphDB.Close()
+		}
+	}
+	// Graph slots saved before the graphs directory are moved into it
+	if err := migrateSlotFiles(); err != nil {
+		fmt.Printf("migrateSlotFiles error: %v\n", err)
+	}
 	// Set up http servers with handler for Graph Options and Dijkstra SP
 	http.HandleFunc(patternDijkstraSP, handleDijkstraSP)
 	http.HandleFunc(patternGraphOptions, handleGraphOptions)
+	http.HandleFunc(patternGridPoint, handleGridPoint)
+	http.HandleFunc(patternMST, handleMST)
+	http.HandleFunc(patternVerifyMST, handleVerifyMST)
+	http.HandleFunc(patternHistogram, handleHistogram)
+	http.HandleFunc(patternQueryLog, handleQueryLog)
+	http.HandleFunc(patternNearest, handleNearest)
+	http.HandleFunc(patternGraphSVG, handleGraphSVG)
+	http.HandleFunc(patternProfile, handleProfile)
+	http.HandleFunc(patternRepro, handleRepro)
+	http.HandleFunc(patternCompare, handleCompare)
+	http.HandleFunc(patternOpenAPI, handleOpenAPI)
+	http.HandleFunc(patternAllPairs, handleAllPairs)
+	http.HandleFunc(patternSP, handleSP)
+	http.HandleFunc(patternDijkstraSVG, handleDijkstraSPSVG)
+	http.HandleFunc(patternDOT, handleDOT)
+	http.HandleFunc(patternSPDebug, handleSPDebug)
+	http.HandleFunc(patternGraphs, handleGraphs)
+	http.HandleFunc(patternHealthz, handleHealthz)
+	// Every path of the OpenAPI description must have a handler
+	if err := checkOpenAPI(http.DefaultServeMux); err != nil {
+		log.Fatalf("checkOpenAPI error: %v\n", err)
+	}
+	// Time out slow clients so they cannot hold connections open indefinitely
+	server := &http.Server{
+		Addr:         addr,
+		ReadTimeout:  *readTimeout,
+		WriteTimeout: *writeTimeout,
+		IdleTimeout:  *idleTimeout,
+	}
 	fmt.Printf("Dijkstra Shortest Path Server listening on %v.\n", addr)
-	http.ListenAndServe(addr, nil)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Printf("ListenAndServe error: %v\n", err)
+	}
 }
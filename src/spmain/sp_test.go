@@ -0,0 +1,947 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+)
+
+// TestUnreachable checks that plotSP reports a target the source cannot reach
+// instead of panicking.  The graph has two clusters without an edge between them,
+// and the second case keeps a finite target distance without its edge, as a broken
+// adjacency would.  Through the page handler the MST edge between the clusters is
+// excluded, and the page answers 200 with the unreachable target in its status.
+func TestUnreachable(t *testing.T) {
+	tempGraphs(t)
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(2, 1), complex(1, 2), complex(8, 8), complex(9, 8), complex(8, 9)}
+	graph := make([][]float64, len(location))
+	for v := range graph {
+		graph[v] = make([]float64, len(location))
+		for w := range graph[v] {
+			graph[v][w] = infinity
+			if v != w && v/3 == w/3 {
+				graph[v][w] = cmplx.Abs(location[v] - location[w])
+			}
+		}
+	}
+	dsp := &DijksraSP{plot: &PlotT{}, location: location, graph: graph, mst: make(MST, len(location)),
+		Endpoints: &bounds, metric: metricEuclidean, source: 0, target: 4, fullGraph: true}
+
+	dsp.searchSP()
+	if err := dsp.plotSP(); err == nil {
+		t.Fatalf("disconnected target %d was plotted", dsp.target)
+	}
+	dsp.distTo[dsp.target] = 1.0
+	if err := dsp.plotSP(); err == nil {
+		t.Fatalf("target %d without an edge was plotted", dsp.target)
+	}
+
+	file, err := slotFile("test-unreachable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &PrimMST{plot: &PlotT{}, location: location, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handleDijkstraSP(w, postForm(url.Values{"slot": {"test-unreachable"}, "sourcevert": {"0"}, "targetvert": {"4"},
+		"excludex": {"5"}, "excludey": {"5"}, "excluderadius": {"1"}}))
+	status := "source vertex 0 and target vertex 4 are in different components of 3 and 3 vertices, there is no SP"
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), template.HTMLEscapeString(status)) {
+		t.Fatalf("page of the unreachable target answered %d without the status %q", w.Code, status)
+	}
+}
+
+// gridCases are points of the bounds x 0 to 10 and y -5 to 5 and their cells in the
+// default grid, where a unit is 29.9 cells and the cell is rounded to the nearest
+var gridCases = []struct {
+	name     string
+	x, y     float64
+	row, col int
+}{
+	{"top left corner", 0, 5, 0, 0},
+	{"top right corner", 10, 5, 0, defaultGridSize - 1},
+	{"bottom left corner", 0, -5, defaultGridSize - 1, 0},
+	{"bottom right corner", 10, -5, defaultGridSize - 1, defaultGridSize - 1},
+	{"center", 5, 0, 150, 150},
+	{"rounded down", 1, 4, 30, 30},
+	{"rounded up", 0.02, 4.98, 1, 1},
+}
+
+// TestToGrid checks the cells of known points, that fromGrid maps each cell back
+// into it and that toCell clamps the points outside the bounds to the border cells
+func TestToGrid(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: -5, xmax: 10, ymax: 5}
+	for _, c := range gridCases {
+		row, col := bounds.toGrid(c.x, c.y)
+		if row != c.row || col != c.col {
+			t.Errorf("%s %g,%g is in row %d column %d, want row %d column %d", c.name, c.x, c.y, row, col, c.row, c.col)
+		}
+		x, y := bounds.fromGrid(row, col, defaultGridSize)
+		if r, c2 := bounds.toGrid(x, y); r != row || c2 != col {
+			t.Errorf("%s cell row %d column %d maps back to row %d column %d", c.name, row, col, r, c2)
+		}
+	}
+
+	plot := &PlotT{view: &bounds, supersample: 1}
+	if row, col := plot.toCell(-1, 6); row != 0 || col != 0 {
+		t.Errorf("point above and left of the bounds is in row %d column %d, want 0 0", row, col)
+	}
+	if row, col := plot.toCell(11, -6); row != defaultGridSize-1 || col != defaultGridSize-1 {
+		t.Errorf("point below and right of the bounds is in row %d column %d, want %d %d", row, col, defaultGridSize-1, defaultGridSize-1)
+	}
+}
+
+// TestOrientation plots a vertex at 2,8 of the bounds 0 to 10 in the math and the
+// screen orientation.  With ymin at the bottom the vertex is drawn in row 60, with
+// ymin at the top in row 239 and not in the mirrored row, and the first y label is
+// ymin or ymax to match.
+func TestOrientation(t *testing.T) {
+	orientations := []struct {
+		screenY bool
+		row     int
+		ylabel  string
+	}{
+		{false, 60, "0.00"},
+		{true, 239, "10.00"},
+	}
+	for _, o := range orientations {
+		bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+		view := bounds
+		view.screenY = o.screenY
+		primmst := &PrimMST{plot: &PlotT{view: &view, supersample: 1}, location: []complex128{complex(2, 8), complex(9, 1)},
+			metric: metricEuclidean, Endpoints: &bounds}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotGrid(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotMST(nil); err != nil {
+			t.Fatal(err)
+		}
+		plot := primmst.plot
+		if row, col := plot.toCell(2, 8); row != o.row || col != 60 {
+			t.Errorf("screen y %v: 2,8 is in row %d column %d, want row %d column 60", o.screenY, row, col, o.row)
+		}
+		mirror := defaultGridSize - 1 - o.row
+		if len(plot.Grid[o.row*defaultGridSize+60]) == 0 || len(plot.Grid[mirror*defaultGridSize+60]) > 0 {
+			t.Errorf("screen y %v: vertex 2,8 drawn as %q in row %d and %q in row %d",
+				o.screenY, plot.Grid[o.row*defaultGridSize+60], o.row, plot.Grid[mirror*defaultGridSize+60], mirror)
+		}
+		if plot.Ylabel[0] != o.ylabel {
+			t.Errorf("screen y %v: first y label %s, want %s", o.screenY, plot.Ylabel[0], o.ylabel)
+		}
+	}
+}
+
+// TestRepeatable checks that SP queries do not change the graph they share.  Each
+// pair is found and plotted, another pair sharing its source is found, then the first
+// pair again; the two answers must be the same and the MST edges must keep their
+// orientation.
+func TestRepeatable(t *testing.T) {
+	rng := rand.New(rand.NewSource(deterministicSeed))
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 100, ymax: 100}
+	location := make([]complex128, searchVertices)
+	for i := range location {
+		location[i] = complex(100*rng.Float64(), 100*rng.Float64())
+	}
+	plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotGrid(); err != nil {
+		t.Fatal(err)
+	}
+	mst := make([]Edge, len(primmst.mst))
+	for i, e := range primmst.mst {
+		if e != nil {
+			mst[i] = *e
+		}
+	}
+
+	query := func(source, target int, fullGraph bool) (string, error) {
+		dsp := newDijkstraSP(primmst)
+		dsp.source, dsp.target, dsp.fullGraph = source, target, fullGraph
+		dsp.searchSP()
+		if err := dsp.plotSP(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v %s", dsp.pathVertices(), dsp.plot.DistanceSP), nil
+	}
+	for pair := 0; pair < searchPairs; pair++ {
+		source, target, other := rng.Intn(searchVertices), rng.Intn(searchVertices), rng.Intn(searchVertices)
+		if source == target || source == other {
+			continue
+		}
+		fullGraph := pair%2 == 1
+		first, err := query(source, target, fullGraph)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := query(source, other, fullGraph); err != nil {
+			t.Fatal(err)
+		}
+		again, err := query(source, target, fullGraph)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != again {
+			t.Fatalf("source %d target %d full graph %v: SP %s, repeated %s", source, target, fullGraph, first, again)
+		}
+	}
+	for i, e := range primmst.mst {
+		if e != nil && *e != mst[i] {
+			t.Fatalf("MST edge %d changed from %v to %v", i, mst[i], *e)
+		}
+	}
+}
+
+// TestMSTStart checks that the MST of a seeded graph has the same edges whichever
+// vertex Prim's algorithm starts from
+func TestMSTStart(t *testing.T) {
+	rng := rand.New(rand.NewSource(deterministicSeed))
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 100, ymax: 100}
+	location := make([]complex128, searchVertices)
+	for i := range location {
+		location[i] = complex(100*rng.Float64(), 100*rng.Float64())
+	}
+	primmst := &PrimMST{plot: &PlotT{}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The edges of the tree as a set, independent of their orientation
+	treeEdges := func() map[Edge]bool {
+		tree := make(map[Edge]bool)
+		for _, e := range primmst.mst.edges() {
+			if e.v < e.w {
+				tree[Edge{v: e.v, w: e.w}] = true
+			} else {
+				tree[Edge{v: e.w, w: e.v}] = true
+			}
+		}
+		return tree
+	}
+	var want map[Edge]bool
+	for start := 0; start < searchVertices; start++ {
+		primmst.start = start
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		got := treeEdges()
+		if want == nil {
+			want = got
+			continue
+		}
+		if len(got) != len(want) {
+			t.Fatalf("start vertex %d: MST has %d edges, %d from vertex 0", start, len(got), len(want))
+		}
+		for e := range got {
+			if !want[e] {
+				t.Fatalf("start vertex %d: MST edge %d-%d is not in the MST from vertex 0", start, e.v, e.w)
+			}
+		}
+	}
+}
+
+// TestWeightedMST finds the MST of a seeded graph with the Euclidean distances and
+// again with a custom weight making one of its edges the longest of the graph.  The
+// MST of the custom weights leaves that edge out.
+func TestWeightedMST(t *testing.T) {
+	rng := rand.New(rand.NewSource(deterministicSeed))
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 100, ymax: 100}
+	location := make([]complex128, searchVertices)
+	for i := range location {
+		location[i] = complex(100*rng.Float64(), 100*rng.Float64())
+	}
+	primmst := &PrimMST{plot: &PlotT{}, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	heavy := *primmst.mst.edges()[0]
+
+	if err := primmst.applyWeights(postForm(url.Values{"edgeweights": {fmt.Sprintf("%d,%d,1000", heavy.v, heavy.w)}})); err != nil {
+		t.Fatal(err)
+	}
+	if w := primmst.graph[heavy.v][heavy.w]; w != 1000 {
+		t.Fatalf("edge %d-%d has weight %g, want the custom weight 1000", heavy.v, heavy.w, w)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range primmst.mst.edges() {
+		if (e.v == heavy.v && e.w == heavy.w) || (e.v == heavy.w && e.w == heavy.v) {
+			t.Fatalf("MST of the custom weights has the Euclidean MST edge %d-%d of weight 1000", e.v, e.w)
+		}
+	}
+}
+
+// TestNearEqualPaths searches a graph of two paths from 0 to 3, through 1 and
+// through 2.  When their lengths differ by less than epsilon they are equal and the
+// path through the lower vertex 1 is found whichever is shorter.  A difference larger
+// than epsilon finds the shorter path.
+func TestNearEqualPaths(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 5), complex(5, 7), complex(5, 3), complex(9, 5)}
+	searches := []struct {
+		through1, through2 float64 // lengths of the second edge of each path
+		via                int
+	}{
+		{1 + epsilon/10, 1, 1},
+		{1, 1 + epsilon/10, 1},
+		{1 + 1e-3, 1, 2},
+	}
+	for _, s := range searches {
+		graph := make([][]float64, len(location))
+		for v := range graph {
+			graph[v] = []float64{infinity, infinity, infinity, infinity}
+		}
+		graph[0][1], graph[1][0], graph[0][2], graph[2][0] = 1, 1, 1, 1
+		graph[1][3], graph[3][1] = s.through1, s.through1
+		graph[2][3], graph[3][2] = s.through2, s.through2
+		dsp := &DijksraSP{plot: &PlotT{}, location: location, graph: graph, mst: make(MST, len(location)),
+			Endpoints: &bounds, metric: metricEuclidean, source: 0, target: 3, fullGraph: true}
+		dsp.searchSP()
+		if path := spPath(dsp); len(path) != 3 || path[1] != s.via {
+			t.Fatalf("paths of %.12g through 1 and %.12g through 2 found %v, want the path through %d",
+				1+s.through1, 1+s.through2, path, s.via)
+		}
+	}
+}
+
+// TestHopOrder searches a graph of two paths of distance 2 from 0 to 4, the path
+// 0-1-2-4 of three hops and the path 0-3-4 of two hops.  The fewest hops find the
+// second path and the most hops the first, whatever the vertex numbers.
+func TestHopOrder(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 5), complex(3, 7), complex(6, 7), complex(5, 3), complex(9, 5)}
+	graph := make([][]float64, len(location))
+	for v := range graph {
+		graph[v] = []float64{infinity, infinity, infinity, infinity, infinity}
+	}
+	for _, e := range []struct {
+		v, w   int
+		weight float64
+	}{{0, 1, 0.5}, {1, 2, 0.5}, {2, 4, 1}, {0, 3, 1}, {3, 4, 1}} {
+		graph[e.v][e.w], graph[e.w][e.v] = e.weight, e.weight
+	}
+	orders := []struct {
+		order string
+		path  []int
+	}{
+		{hopsFewest, []int{0, 3, 4}},
+		{hopsMost, []int{0, 1, 2, 4}},
+	}
+	for _, o := range orders {
+		dsp := &DijksraSP{plot: &PlotT{}, location: location, graph: graph, mst: make(MST, len(location)),
+			Endpoints: &bounds, metric: metricEuclidean}
+		err := dsp.findSP(postForm(url.Values{"sourcevert": {"0"}, "targetvert": {"4"}, "fullgraph": {"on"}, "hoporder": {o.order}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path := spPath(dsp); fmt.Sprint(path) != fmt.Sprint(o.path) || dsp.distTo[4] != 2 {
+			t.Fatalf("%s hops found %v of distance %g, want %v of distance 2", o.order, path, dsp.distTo[4], o.path)
+		}
+	}
+}
+
+// TestUnreachedRelax searches a triangle and, apart from it, an edge between two
+// vertices the source never reaches.  The edge weight of -1e300 would lower even the
+// largest finite distance.  Every Bellman-Ford pass relaxes the whole graph, and the
+// edge from its unreached vertex leaves the distances of both vertices infinite, so
+// it neither lowers them nor is reported as a negative cycle.
+func TestUnreachedRelax(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(5, 5), complex(9, 2), complex(2, 8), complex(4, 9)}
+	graph := make([][]float64, len(location))
+	for v := range graph {
+		graph[v] = make([]float64, len(location))
+		for w := range graph[v] {
+			graph[v][w] = infinity
+			if v != w && v < 3 && w < 3 {
+				graph[v][w] = cmplx.Abs(location[v] - location[w])
+			}
+		}
+	}
+	graph[3][4], graph[4][3] = -1e300, -1e300
+	dsp := &DijksraSP{plot: &PlotT{EdgeWeights: "3,4,-1e300"}, location: location, graph: graph,
+		mst: make(MST, len(location)), Endpoints: &bounds, metric: metricEuclidean}
+	if err := dsp.findSPBellmanFord(postForm(url.Values{"sourcevert": {"0"}, "targetvert": {"2"}, "fullgraph": {"on"}})); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int{3, 4} {
+		if dsp.distTo[v] != infinity || dsp.edgeTo[v] != nil {
+			t.Fatalf("unreached vertex %d has distance %g", v, dsp.distTo[v])
+		}
+	}
+	if d := dsp.distTo[2]; d != graph[0][2] {
+		t.Fatalf("SP to vertex 2 has distance %g, want %g", d, graph[0][2])
+	}
+}
+
+// TestPriorityQueue pushes, updates and pops many items of the priority queue and
+// checks that they come out in non-decreasing distance order, each vertex once.
+func TestPriorityQueue(t *testing.T) {
+	const items = 5000
+	rng := rand.New(rand.NewSource(deterministicSeed))
+	pq := newPriorityQueue()
+	for w := 0; w < items; w++ {
+		heap.Push(&pq, &Item{Edge: Edge{v: w, w: w}, distance: 1000 * rng.Float64()})
+		// Lower or raise the distance of a random queued vertex, as Prim and Dijkstra do
+		if item, ok := pq.find(rng.Intn(w + 1)); ok {
+			pq.update(item, 1000*rng.Float64())
+		}
+		// Pop now and then so the updates also happen after removals
+		if w%7 == 0 {
+			heap.Pop(&pq)
+		}
+	}
+
+	popped := make(map[int]bool)
+	last := -1.0
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(*Item)
+		if item.distance < last {
+			t.Fatalf("vertex %d distance %g popped after %g", item.w, item.distance, last)
+		}
+		if popped[item.w] {
+			t.Fatalf("vertex %d popped twice", item.w)
+		}
+		if _, ok := pq.find(item.w); ok {
+			t.Fatalf("popped vertex %d is still queued", item.w)
+		}
+		last = item.distance
+		popped[item.w] = true
+	}
+	if want := items - (items+6)/7; len(popped) != want {
+		t.Fatalf("%d vertices popped at the end, %d were queued", len(popped), want)
+	}
+}
+
+// TestGraphSessions interleaves the requests of two sessions, each generating a graph
+// without a slot and then asking for an SP, and checks that each SP request reads
+// back the graph of its own session
+func TestGraphSessions(t *testing.T) {
+	tempGraphs(t)
+	generate := func(seed string) (*PrimMST, error) {
+		p := &PrimMST{plot: &PlotT{}}
+		err := p.generateVertices(postForm(url.Values{"vertices": {"20"}, "seed": {seed},
+			"xmin": {"-10"}, "ymin": {"-10"}, "xmax": {"10"}, "ymax": {"10"}}))
+		return p, err
+	}
+	reload := func(slot string) (*PrimMST, error) {
+		p := &PrimMST{plot: &PlotT{}}
+		err := p.generateVertices(postForm(url.Values{"slot": {slot}, "sourcevert": {"0"}, "targetvert": {"1"}}))
+		return p, err
+	}
+	sameGraph := func(a, b *PrimMST) bool {
+		if len(a.location) != len(b.location) {
+			return false
+		}
+		for i := range a.location {
+			if cmplx.Abs(a.location[i]-b.location[i]) > 1e-5 {
+				return false
+			}
+		}
+		return true
+	}
+
+	a, err := generate("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generate("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.plot.Slot) == 0 || a.plot.Slot == b.plot.Slot {
+		t.Fatalf("sessions got graph IDs %q and %q", a.plot.Slot, b.plot.Slot)
+	}
+
+	// Session a asks for an SP after b generated, then b after a generated again
+	readA, err := reload(a.plot.Slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := generate("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readB, err := reload(b.plot.Slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameGraph(readA, a) || sameGraph(readA, b) {
+		t.Fatalf("session graph %s does not read back its own vertices", a.plot.Slot)
+	}
+	if !sameGraph(readB, b) || sameGraph(readB, a2) {
+		t.Fatalf("session graph %s does not read back its own vertices", b.plot.Slot)
+	}
+}
+
+// TestConcurrentVertices saves and reads the vertex file of one slot from many
+// goroutines at once, as concurrent requests do, and checks that every read gets a
+// whole graph.  Run it with go test -race to also check the locking.
+func TestConcurrentVertices(t *testing.T) {
+	tempGraphs(t)
+	const (
+		slot    = "test-concurrent"
+		writers = 4
+		readers = 8
+		rounds  = 25
+	)
+	form := func(seed int) url.Values {
+		return url.Values{"slot": {slot}, "vertices": {"20"}, "seed": {fmt.Sprint(seed)},
+			"xmin": {"-10"}, "ymin": {"-10"}, "xmax": {"10"}, "ymax": {"10"}}
+	}
+	p := &PrimMST{plot: &PlotT{}}
+	if err := p.generateVertices(postForm(form(0))); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers+readers)
+	for i := 0; i < writers+readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				p := &PrimMST{plot: &PlotT{}}
+				if i < writers {
+					if err := p.generateVertices(postForm(form(i*rounds + round))); err != nil {
+						errs <- err
+						return
+					}
+					continue
+				}
+				err := p.generateVertices(postForm(url.Values{"slot": {slot}, "sourcevert": {"0"}, "targetvert": {"1"}}))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(p.location) != 20 {
+					errs <- fmt.Errorf("read %d vertices of a 20 vertex graph", len(p.location))
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCoincident plots the MST and SP of a graph with two vertices at the same
+// location and one a hair away from them, and checks that the grid is whole and
+// the SP between the coincident vertices has zero distance
+func TestCoincident(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(5, 5), complex(5, 5), complex(5, 5+1e-12), complex(8, 2), complex(10, 10), complex(10, 10)}
+	plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotGrid(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotMST(nil); err != nil {
+		t.Fatal(err)
+	}
+	dsp := newDijkstraSP(primmst)
+	for _, pair := range [][2]int{{0, 1}, {4, 5}} {
+		dsp.source, dsp.target = pair[0], pair[1]
+		dsp.searchSP()
+		if err := dsp.plotSP(); err != nil {
+			t.Fatal(err)
+		}
+		if want := plot.withUnits("0.00"); plot.DistanceSP != want {
+			t.Fatalf("coincident vertices %d and %d SP distance %s, want %s", pair[0], pair[1], plot.DistanceSP, want)
+		}
+	}
+
+	if len(plot.Grid) != defaultGridSize*defaultGridSize {
+		t.Fatalf("grid has %d cells, want %d", len(plot.Grid), defaultGridSize*defaultGridSize)
+	}
+	for _, z := range location {
+		row, col := plot.toCell(real(z), imag(z))
+		if len(plot.Grid[row*defaultGridSize+col]) == 0 {
+			t.Fatalf("vertex at %v is not drawn", z)
+		}
+	}
+}
+
+// TestCornerMarkers draws a marker on each of the four corners of the grid, plain
+// and supersampled, and checks that only the center and the two arms pointing into
+// the grid are drawn
+func TestCornerMarkers(t *testing.T) {
+	bounds := Endpoints{xmin: -3, ymin: -2, xmax: 5, ymax: 4}
+	corners := []complex128{complex(bounds.xmin, bounds.ymin), complex(bounds.xmin, bounds.ymax),
+		complex(bounds.xmax, bounds.ymin), complex(bounds.xmax, bounds.ymax)}
+	for _, supersample := range []int{1, 2} {
+		for _, z := range corners {
+			plot := &PlotT{view: &bounds, supersample: supersample,
+				Grid: make([]string, defaultGridSize*defaultGridSize*supersample*supersample)}
+			plot.setMarker(z, "vertexSP1")
+			drawn := 0
+			for _, class := range plot.Grid {
+				if len(class) > 0 {
+					drawn++
+				}
+			}
+			if want := 1 + 2*supersample; drawn != want {
+				t.Fatalf("marker on corner %v with supersample %d drew %d cells, want %d", z, supersample, drawn, want)
+			}
+		}
+	}
+}
+
+// TestDrawPrecedence draws the source marker and a vertex on a horizontal line, then
+// an SP edge and an MST edge along the line through both.  The marker and the vertex
+// keep their cells whichever is drawn first, and the MST edge covers no cell of the
+// SP edge.
+func TestDrawPrecedence(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	plot := &PlotT{view: &bounds, supersample: 1, Grid: make([]string, defaultGridSize*defaultGridSize)}
+	cell := func(x, y float64) string {
+		row, col := plot.toCell(x, y)
+		return plot.Grid[row*defaultGridSize+col]
+	}
+	plot.setMarker(complex(5, 5), "vertexSP1")
+	plot.drawEdge(complex(1, 5), complex(9, 5), "edgeSP")
+	plot.setCell(3, 5, "vertex")
+	plot.drawEdge(complex(1, 5), complex(9, 5), "edge")
+	if class := cell(5, 5); class != "vertexSP1" {
+		t.Errorf("marker cell drawn before the edges is %q, want vertexSP1", class)
+	}
+	if class := cell(3, 5); class != "vertex" {
+		t.Errorf("vertex cell drawn after the SP edge and before the MST edge is %q, want vertex", class)
+	}
+	sp := 0
+	for _, class := range plot.Grid {
+		if class == "edge" {
+			t.Fatalf("MST edge covers a cell of the SP edge")
+		}
+		if class == "edgeSP" {
+			sp++
+		}
+	}
+	if sp == 0 {
+		t.Errorf("SP edge is not drawn")
+	}
+}
+
+// TestAllEdges draws every edge of a complete graph beneath its MST and checks that
+// each edge is drawn and that the MST edges are not covered by the graph edges
+func TestAllEdges(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(9, 1), complex(9, 9), complex(1, 9)}
+	plot := &PlotT{Units: defaultUnits, view: &bounds, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotGrid(); err != nil {
+		t.Fatal(err)
+	}
+	if edges := primmst.plotGraphEdges(); edges != 6 {
+		t.Fatalf("complete graph of 4 vertices drew %d edges, want 6", edges)
+	}
+	if err := primmst.plotMST(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range primmst.mst.edges() {
+		mid := (location[e.v] + location[e.w]) / 2
+		row, col := plot.toCell(real(mid), imag(mid))
+		if class := plot.Grid[row*defaultGridSize+col]; class != "edge" {
+			t.Fatalf("MST edge %d-%d midpoint is %q, want edge", e.v, e.w, class)
+		}
+	}
+	// The diagonals are not MST edges, they cross at the center
+	row, col := plot.toCell(5, 5)
+	if class := plot.Grid[row*defaultGridSize+col]; class != "edgeGraph" {
+		t.Fatalf("graph edge crossing is %q, want edgeGraph", class)
+	}
+}
+
+// TestTemplateError renders the page of a saved graph and of a form error with a
+// template failing on a missing field and checks that the handler answers 500 and
+// keeps running, then renders the graph again with the page template
+func TestTemplateError(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+	graph := url.Values{"slot": {"test-template"}, "sourcevert": {"0"}, "targetvert": {"2"}}
+
+	page := tmplForm
+	defer func() { tmplForm = page }()
+	tmplForm = template.Must(template.New("broken").Parse("{{.NoSuchField}}"))
+	for _, form := range []url.Values{graph, {"vertices": {"1"}}} {
+		w := httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(form))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("form %s with a broken template answered %d, want 500", form.Encode(), w.Code)
+		}
+	}
+
+	tmplForm = page
+	w := httptest.NewRecorder()
+	handleDijkstraSP(w, postForm(graph))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<html") {
+		t.Fatalf("graph page after the template error answered %d", w.Code)
+	}
+}
+
+// scriptPayload closes the attribute or element of an echoed form value and opens a
+// script, the page must show it as text
+const scriptPayload = `"></textarea><script>alert(1)</script>`
+
+// escapeCases are forms echoing the script payload in the page
+var escapeCases = []struct {
+	name string
+	form url.Values
+}{
+	{"units", url.Values{"units": {scriptPayload}}},
+	{"status of a bad hop order", url.Values{"hoporder": {scriptPayload}}},
+	{"edge weights", url.Values{"edgeweights": {"0,1,2\n" + scriptPayload}}},
+	{"obstacles", url.Values{"obstacles": {"1,1,2,2\n" + scriptPayload}}},
+	{"terminals", url.Values{"terminals": {"0,1," + scriptPayload}}},
+	{"seed", url.Values{"seed": {scriptPayload}}},
+	{"MST start vertex", url.Values{"startvert": {scriptPayload}}},
+	{"K shortest paths", url.Values{"k": {scriptPayload}}},
+	{"edge probability", url.Values{"edgeprob": {scriptPayload}}},
+	{"kNN", url.Values{"knn": {scriptPayload}}},
+	{"maximum turns", url.Values{"maxturns": {scriptPayload}}},
+	{"through fraction", url.Values{"through": {scriptPayload}}},
+	{"demo pairs", url.Values{"demopairs": {scriptPayload}}},
+}
+
+// TestPageEscapes renders the SP page of a saved graph with the script payload in
+// each echoed form value and checks that the page has no script element
+func TestPageEscapes(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-escape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range escapeCases {
+		form := url.Values{"slot": {"test-escape"}, "sourcevert": {"0"}, "targetvert": {"2"}}
+		for name, values := range c.form {
+			form[name] = values
+		}
+		w := httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(form))
+		if strings.Contains(w.Body.String(), "<script>") {
+			t.Errorf("%s is echoed without escaping", c.name)
+		}
+	}
+}
+
+// TestVertexCounts generates graphs with too few or too many vertices and with bad
+// bounds and checks that the page shows why in its status instead of panicking, and
+// that /api/sp rejects the same counts
+func TestVertexCounts(t *testing.T) {
+	bounds := url.Values{"xmin": {"0"}, "ymin": {"0"}, "xmax": {"10"}, "ymax": {"10"}}
+	forms := map[string]url.Values{}
+	for _, verts := range []string{"0", "1", "-3", "1000000000", "many"} {
+		form := url.Values{"vertices": {verts}}
+		for k, v := range bounds {
+			form[k] = v
+		}
+		forms[verts+" vertices"] = form
+	}
+	forms["degenerate bounds"] = url.Values{"vertices": {"10"}, "xmin": {"5"}, "ymin": {"0"}, "xmax": {"5"}, "ymax": {"10"}}
+	forms["infinite bounds"] = url.Values{"vertices": {"10"}, "xmin": {"-Inf"}, "ymin": {"0"}, "xmax": {"10"}, "ymax": {"10"}}
+	forms["NaN bounds"] = url.Values{"vertices": {"10"}, "xmin": {"0"}, "ymin": {"NaN"}, "xmax": {"10"}, "ymax": {"10"}}
+
+	for name, form := range forms {
+		p := &PrimMST{plot: &PlotT{}}
+		err := p.generateVertices(postForm(form))
+		if err == nil {
+			os.Remove(p.file)
+			t.Fatalf("graph of %s generated %d vertices", name, len(p.location))
+		}
+		w := httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(form))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), template.HTMLEscapeString(err.Error())) {
+			t.Fatalf("page of %s answered %d without the reason in its status", name, w.Code)
+		}
+	}
+
+	for _, verts := range []int{0, 1, maxVertices + 1} {
+		req := SPRequestT{Vertices: verts, Xmax: 10, Ymax: 10, Target: 1}
+		if _, code, err := computeSP(req); err == nil || code != apiInvalidInput {
+			t.Fatalf("SP of %d vertices gave code %q error %v", verts, code, err)
+		}
+	}
+}
+
+// TestTargetOutOfRange asks the page for the SP of a saved graph to a target out of
+// range.  The page answers 200 with the MST and the one SP error as its status, and
+// /api/sp answers 400 invalid_input for the same target.
+func TestTargetOutOfRange(t *testing.T) {
+	tempGraphs(t)
+	file, err := slotFile("test-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	p := &PrimMST{plot: &PlotT{}, location: []complex128{complex(1, 1), complex(5, 5), complex(9, 2)}, Endpoints: &bounds, file: file}
+	if err := p.saveVertices(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handleDijkstraSP(w, postForm(url.Values{"slot": {"test-target"}, "sourcevert": {"0"}, "targetvert": {"3"}}))
+	page := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(page, `name="status" value="source and/or target vertices are invalid"`) {
+		t.Fatalf("page of target 3 of 3 vertices answered %d without the SP error as its status", w.Code)
+	}
+	if strings.Contains(page, `id="distance" name="distance" value=""`) {
+		t.Fatalf("page of target 3 of 3 vertices has no MST distance")
+	}
+
+	r := httptest.NewRequest(http.MethodPost, patternSP, strings.NewReader(`{"vertices": 10, "xmax": 10, "ymax": 10, "source": 0, "target": 10}`))
+	w = httptest.NewRecorder()
+	handleSP(w, r)
+	var apiErr APIErrorT
+	if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || apiErr.Error.Code != apiInvalidInput {
+		t.Fatalf("/api/sp of target 10 of 10 vertices answered %d %s, want %d %s",
+			w.Code, apiErr.Error.Code, http.StatusBadRequest, apiInvalidInput)
+	}
+}
+
+// TestDegenerateBounds checks that graph bounds of an equal or a nearly equal x
+// range, also when swapped, are rejected and that a range of twice minSpan is not.
+// A plotted region of equal and of nearly equal bounds, as a vertex file may have, is
+// widened, so the labels are finite numbers and the vertex is drawn near the center
+// of the grid.
+func TestDegenerateBounds(t *testing.T) {
+	for _, b := range [][4]float64{{5, 0, 5, 10}, {5, 0, 5 + minSpan/10, 10}, {5 + minSpan/10, 0, 5, 10}} {
+		if _, err := newEndpoints(b[0], b[1], b[2], b[3]); err == nil {
+			t.Fatalf("bounds %v are not rejected", b)
+		}
+	}
+	if _, err := newEndpoints(5, 0, 5+2*minSpan, 10); err != nil {
+		t.Fatalf("x range of %g is rejected: %v", 2*minSpan, err)
+	}
+
+	for _, view := range []Endpoints{{xmin: 5, ymin: 5, xmax: 5, ymax: 5}, {xmin: 5, ymin: 5, xmax: 5 + minSpan/10, ymax: 5 + minSpan/10}} {
+		view := view
+		primmst := &PrimMST{plot: &PlotT{view: &view, supersample: 1}, location: []complex128{complex(5, 5), complex(5, 5)},
+			metric: metricEuclidean, Endpoints: &view}
+		if err := primmst.findDistances(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.findMST(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotGrid(); err != nil {
+			t.Fatal(err)
+		}
+		if err := primmst.plotMST(nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range append(primmst.plot.Xlabel, primmst.plot.Ylabel...) {
+			if x, err := strconv.ParseFloat(label, 64); err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+				t.Fatalf("bounds %+v have the label %q", view, label)
+			}
+		}
+		// The widened region has the vertex near its center, far from the border
+		row, col := primmst.plot.toCell(5, 5)
+		if row < defaultGridSize/4 || row > 3*defaultGridSize/4 || col < defaultGridSize/4 || col > 3*defaultGridSize/4 ||
+			len(primmst.plot.Grid[row*defaultGridSize+col]) == 0 {
+			t.Fatalf("bounds %+v draw the vertex in row %d column %d as %q", view, row, col, primmst.plot.Grid[row*defaultGridSize+col])
+		}
+	}
+}
+
+// TestSeededDraws asks twice for the demo pairs and the sampled through fraction of a
+// seeded graph, with the global generator moved on in between, and perturbs copies of
+// its vertices with generators of one seed.  The seed of the graph repeats them all.
+func TestSeededDraws(t *testing.T) {
+	tempGraphs(t)
+	graph := url.Values{"slot": {"test-seeded"}, "vertices": {"20"}, "seed": {"5"},
+		"xmin": {"0"}, "ymin": {"0"}, "xmax": {"10"}, "ymax": {"10"}}
+	handleDijkstraSP(httptest.NewRecorder(), postForm(graph))
+
+	// Draw the pairs again rather than serve the first page from the render cache
+	renderCacheSize := renders.size
+	defer func() { renders.size = renderCacheSize }()
+	renders.size = 0
+	form := url.Values{"slot": {"test-seeded"}, "seed": {"5"}, "sourcevert": {"0"}, "targetvert": {"9"},
+		"demo": {"on"}, "demopairs": {"3"}, "through": {"4"}, "throughsamples": {"5"}}
+	pages := make([]string, 2)
+	for i := range pages {
+		rand.Int63()
+		w := httptest.NewRecorder()
+		handleDijkstraSP(w, postForm(form))
+		pages[i] = w.Body.String()
+	}
+	if !strings.Contains(pages[0], "edgeDemo") || pages[0] != pages[1] {
+		t.Fatalf("demo and through pages of seed 5 differ")
+	}
+
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	moved := make([][]complex128, 2)
+	for i := range moved {
+		p := &PrimMST{plot: &PlotT{}, Endpoints: &bounds, location: make([]complex128, 20)}
+		if err := p.perturbVertices(50, rand.New(rand.NewSource(5))); err != nil {
+			t.Fatal(err)
+		}
+		moved[i] = p.location
+	}
+	for v := range moved[0] {
+		if moved[0][v] != moved[1][v] {
+			t.Fatalf("vertex %d perturbed to %v and %v with seed 5", v, moved[0][v], moved[1][v])
+		}
+	}
+}
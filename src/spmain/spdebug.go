@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+)
+
+// Type to contain the SP search state returned by /api/spdebug
+type SPDebugT struct {
+	Source  int        `json:"source"`
+	Target  int        `json:"target"`
+	Settled int        `json:"settled"`        // vertices removed from the priority queue
+	DistTo  []*float64 `json:"distto"`         // distance from the source, null if not reached
+	EdgeTo  []int      `json:"edgeto"`         // parent vertex in the SP tree, -1 for the source and the unreached vertices
+	Done    []bool     `json:"done,omitempty"` // settled vertices, their distance is final, omitted for a cached search
+	Path    []int      `json:"path,omitempty"` // SP vertices from source to target, omitted if there is none
+}
+
+// debug returns the distances and parent vertices of the search by findSP.  A vertex
+// that was reached but not settled before the search stopped at the target has a
+// tentative distance.
+func (dsp *DijksraSP) debug() SPDebugT {
+	debug := SPDebugT{
+		Source:  dsp.source,
+		Target:  dsp.target,
+		Settled: dsp.settled,
+		DistTo:  make([]*float64, len(dsp.distTo)),
+		EdgeTo:  make([]int, len(dsp.edgeTo)),
+		Path:    dsp.pathVertices(),
+	}
+	for v, d := range dsp.distTo {
+		if d < infinity {
+			d := d
+			debug.DistTo[v] = &d
+		}
+	}
+	for w, e := range dsp.edgeTo {
+		debug.EdgeTo[w] = -1
+		if e != nil {
+			// the parent is the endpoint that is not w
+			debug.EdgeTo[w] = e.v
+			if e.v == w {
+				debug.EdgeTo[w] = e.w
+			}
+		}
+	}
+	if len(dsp.settledTo) == len(dsp.distTo) {
+		debug.Done = dsp.settledTo
+	}
+	return debug
+}
+
+// HTTP handler for /api/spdebug connections.  It finds the SP between the sourcevert
+// and targetvert of the saved graph and returns the distance and parent of every
+// vertex, to diagnose a wrong path.
+func handleSPDebug(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	dsp := newDijkstraSP(primmst)
+	if err := dsp.findSP(r); err != nil {
+		writeAPIError(w, apiCode(err), err.Error())
+		return
+	}
+
+	writeJSON(w, dsp.debug())
+}
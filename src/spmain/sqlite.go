@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package main
+
+// The SQLite driver of the graph database, required in go.mod.  It is only built
+// with -tags sqlite, so the default build does not compile the large driver.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseTerminals gets the comma-separated terminal vertices from the HTML form
+func (dsp *DijksraSP) parseTerminals(r *http.Request) ([]int, error) {
+	vertices := len(dsp.location)
+	terminals := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, str := range strings.Split(r.PostFormValue("terminals"), ",") {
+		str = strings.TrimSpace(str)
+		if len(str) == 0 {
+			continue
+		}
+		v, err := strconv.Atoi(str)
+		if err != nil {
+			fmt.Printf("String %s conversion to int error: %v\n", str, err)
+			return nil, err
+		}
+		if v < 0 || v > vertices-1 {
+			return nil, fmt.Errorf("terminal vertex %d must be 0-%d", v, vertices-1)
+		}
+		if !seen[v] {
+			seen[v] = true
+			terminals = append(terminals, v)
+		}
+	}
+	if len(terminals) < 2 {
+		return nil, fmt.Errorf("enter at least two terminal vertices")
+	}
+
+	return terminals, nil
+}
+
+// findSteiner connects the terminal vertices using the MST of the complete graph of
+// shortest path distances between the terminals, a 2-approximation of the Steiner
+// tree.  The MST edges of the reduced graph are mapped back to the shortest paths
+// and the union of the path edges is drawn in the grid.
+func (dsp *DijksraSP) findSteiner(terminals []int) error {
+	if len(dsp.adj) == 0 {
+		dsp.buildAdjacency()
+	}
+
+	// Shortest paths from every terminal to all the vertices
+	k := len(terminals)
+	distTo := make([][]float64, k)
+	prev := make([][]int, k)
+	for i, t := range terminals {
+		distTo[i], prev[i] = dsp.shortestFrom(t)
+	}
+
+	// Prim's algorithm on the complete graph of the terminals, it is small and dense
+	// so the closest terminal is found by a linear scan instead of a priority queue.
+	inTree := make([]bool, k)
+	best := make([]float64, k)
+	parent := make([]int, k)
+	for i := range best {
+		best[i] = infinity
+		parent[i] = -1
+	}
+	best[0] = 0.0
+	for n := 0; n < k; n++ {
+		u := -1
+		for i := 0; i < k; i++ {
+			if !inTree[i] && (u < 0 || lessDistance(best[i], best[u])) {
+				u = i
+			}
+		}
+		if best[u] == infinity {
+			return fmt.Errorf("terminal vertex %d is not reachable from terminal vertex %d", terminals[u], terminals[0])
+		}
+		inTree[u] = true
+		for i := 0; i < k; i++ {
+			if !inTree[i] && lessDistance(distTo[u][terminals[i]], best[i]) {
+				best[i] = distTo[u][terminals[i]]
+				parent[i] = u
+			}
+		}
+	}
+
+	// Map each reduced edge back to its shortest path and keep the union of the edges
+	type pair struct{ v, w int }
+	used := make(map[pair]bool)
+	distance := 0.0
+	for i := 1; i < k; i++ {
+		u := parent[i]
+		for w := terminals[i]; w != terminals[u]; w = prev[u][w] {
+			v := prev[u][w]
+			key := pair{v, w}
+			if v > w {
+				key = pair{w, v}
+			}
+			if used[key] {
+				continue
+			}
+			used[key] = true
+			distance += dsp.graph[v][w]
+			dsp.plot.drawEdge(dsp.location[v], dsp.location[w], "edgeSteiner")
+		}
+	}
+
+	// Mark the terminals.  CSS colors the terminal DarkOrange.
+	for _, t := range terminals {
+		dsp.plot.setMarker(dsp.location[t], "terminal")
+	}
+
+	dsp.plot.SteinerEdges = strconv.Itoa(len(used))
+	dsp.plot.SteinerDistance = dsp.plot.withUnits(fmt.Sprintf("%.2f", distance))
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	svgSize      = 600       // width and height of the SVG image in pixels
+	svgPathColor = "#ffcc00" // yellow SP edges of /dijkstrasp.svg unless pathcolor is given
+)
+
+// svgStyle colors the SVG elements with the classes of the grid
+const svgStyle = `
+.edge { stroke: #ddd; stroke-width: 2; }
+.edgeSP { stroke: orange; stroke-width: 3; }
+.vertex { fill: #000; }
+.startvertexMSS { fill: #0f0; stroke: #000; }
+.vertexSP1 { fill: blue; stroke: #000; }
+.vertexSP2 { fill: red; stroke: #000; }
+.label { font: 12px sans-serif; }
+`
+
+// svgPoint converts the Euclidean graph x,y coordinates to SVG pixel coordinates.
+// Unlike the grid they are not rounded, so the image stays crisp at any zoom.
+func (ep *Endpoints) svgPoint(z complex128) (float64, float64) {
+	xscale := (svgSize - 1) / (ep.xmax - ep.xmin)
+	yscale := (svgSize - 1) / (ep.ymax - ep.ymin)
+
+	y := (ep.ymax - imag(z)) * yscale
+	if ep.screenY {
+		y = (imag(z) - ep.ymin) * yscale
+	}
+	return (real(z) - ep.xmin) * xscale, y
+}
+
+// svgLine writes the SVG line between the locations.  On a torus an edge crossing
+// the bounds is split into the two segments leaving the image.
+func svgLine(w io.Writer, ep, torus *Endpoints, start, end complex128, class string) {
+	line := func(a, b complex128) {
+		x1, y1 := ep.svgPoint(a)
+		x2, y2 := ep.svgPoint(b)
+		fmt.Fprintf(w, "<line class=\"%s\" x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" />\n", class, x1, y1, x2, y2)
+	}
+	if torus != nil && torus.isWrapped(start, end) {
+		d := torus.wrap(start, end)
+		line(start, start+d)
+		line(end, end-d)
+		return
+	}
+	line(start, end)
+}
+
+// svgIcon writes the SVG icon of the legend shape of the class at the location
+func svgIcon(w io.Writer, ep *Endpoints, z complex128, class string) {
+	shape := "circle"
+	for _, entry := range legend {
+		if entry.Class == class {
+			shape = entry.Shape
+		}
+	}
+	x, y := ep.svgPoint(z)
+	const r = 6
+	switch shape {
+	case "diamond":
+		fmt.Fprintf(w, "<polygon class=\"%s\" points=\"%.2f,%.2f %.2f,%.2f %.2f,%.2f %.2f,%.2f\" />\n", class, x, y-r, x+r, y, x, y+r, x-r, y)
+	case "triangle":
+		fmt.Fprintf(w, "<polygon class=\"%s\" points=\"%.2f,%.2f %.2f,%.2f %.2f,%.2f\" />\n", class, x, y-r, x+r, y+r, x-r, y+r)
+	case "square":
+		fmt.Fprintf(w, "<rect class=\"%s\" x=\"%.2f\" y=\"%.2f\" width=\"%d\" height=\"%d\" />\n", class, x-r, y-r, 2*r, 2*r)
+	default:
+		fmt.Fprintf(w, "<circle class=\"%s\" cx=\"%.2f\" cy=\"%.2f\" r=\"%d\" />\n", class, x, y, r)
+	}
+}
+
+// svgVertices writes a circle for every vertex.  The data attributes and the title
+// show the vertex index and location when hovering in the browser.
+func svgVertices(w io.Writer, ep *Endpoints, location []complex128, units string) {
+	for i, z := range location {
+		x, y := ep.svgPoint(z)
+		fmt.Fprintf(w, "<circle class=\"vertex\" cx=\"%.2f\" cy=\"%.2f\" r=\"3\" data-index=\"%d\" data-x=\"%f\" data-y=\"%f\">"+
+			"<title>vertex %d (%.2f, %.2f) %s</title></circle>\n", x, y, i, real(z), imag(z), i, real(z), imag(z), html.EscapeString(units))
+	}
+}
+
+// svgLabel writes the circle of an SP end vertex and its label.  The label is on
+// the inner side of the vertex so it is not clipped by the image.
+func svgLabel(w io.Writer, ep *Endpoints, z complex128, class, label string) {
+	x, y := ep.svgPoint(z)
+	fmt.Fprintf(w, "<circle class=\"%s\" cx=\"%.2f\" cy=\"%.2f\" r=\"6\" />\n", class, x, y)
+	anchor, dx, dy := "start", 9.0, -9.0
+	if x > svgSize/2 {
+		anchor, dx = "end", -9
+	}
+	if y < svgSize/2 {
+		dy = 18
+	}
+	fmt.Fprintf(w, "<text class=\"label\" x=\"%.2f\" y=\"%.2f\" text-anchor=\"%s\">%s</text>\n",
+		x+dx, y+dy, anchor, html.EscapeString(label))
+}
+
+// svgHeader writes the SVG root element and the style.  A transparent image has no
+// background rectangle so it can be laid over a map.
+func svgHeader(w http.ResponseWriter, plot *PlotT) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		svgSize, svgSize, svgSize, svgSize)
+	fmt.Fprintf(w, "<style>%s%s</style>\n", svgStyle, plot.svgOverlayStyle())
+	if len(plot.Transparent) == 0 {
+		fmt.Fprintf(w, "<rect width=\"%d\" height=\"%d\" fill=\"white\" stroke=\"black\" />\n", svgSize, svgSize)
+	}
+}
+
+// HTTP handler for /graph.svg connections.  It draws the MST of the saved graph as
+// SVG with a tooltip on every vertex.
+func handleGraphSVG(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+	units := r.FormValue("units")
+	if len(units) == 0 {
+		units = defaultUnits
+	}
+	plot := &PlotT{}
+	if err := plot.parseOverlay(r); err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	var torus *Endpoints
+	if primmst.metric == metricToroidal {
+		torus = primmst.Endpoints
+	}
+
+	var b strings.Builder
+	for _, e := range primmst.mst.edges() {
+		svgLine(&b, primmst.Endpoints, torus, primmst.location[e.v], primmst.location[e.w], "edge")
+	}
+	svgVertices(&b, primmst.Endpoints, primmst.location, units)
+	svgIcon(&b, primmst.Endpoints, primmst.location[primmst.start], "startvertexMSS")
+
+	svgHeader(w, plot)
+	io.WriteString(w, b.String())
+	io.WriteString(w, "</svg>\n")
+}
+
+// HTTP handler for /dijkstrasp.svg connections.  It draws the vertices, the MST and
+// the SP between sourcevert and targetvert of the saved graph as SVG at the real
+// vertex locations, with the source and target labeled.
+func handleDijkstraSPSVG(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+	plot := &PlotT{}
+	if err := plot.parseOverlay(r); err != nil {
+		writeAPIError(w, apiInvalidInput, err.Error())
+		return
+	}
+	if len(plot.PathColor) == 0 {
+		plot.PathColor = svgPathColor
+	}
+
+	dsp := newDijkstraSP(primmst)
+	if err := dsp.findSP(r); err != nil {
+		writeAPIError(w, apiCode(err), err.Error())
+		return
+	}
+	path := dsp.pathVertices()
+	if path == nil {
+		writeAPIError(w, apiNoPath, fmt.Sprintf("target vertex %d unreachable from source vertex %d", dsp.target, dsp.source))
+		return
+	}
+	var torus *Endpoints
+	if dsp.metric == metricToroidal {
+		torus = dsp.Endpoints
+	}
+
+	var b strings.Builder
+	for _, e := range dsp.mst.edges() {
+		svgLine(&b, dsp.Endpoints, torus, dsp.location[e.v], dsp.location[e.w], "edge")
+	}
+	for i := 1; i < len(path); i++ {
+		svgLine(&b, dsp.Endpoints, torus, dsp.location[path[i-1]], dsp.location[path[i]], "edgeSP")
+	}
+	svgVertices(&b, dsp.Endpoints, dsp.location, defaultUnits)
+	svgLabel(&b, dsp.Endpoints, dsp.location[dsp.source], "vertexSP1", "source "+vertexName(dsp.names, dsp.source))
+	svgLabel(&b, dsp.Endpoints, dsp.location[dsp.target], "vertexSP2", "target "+vertexName(dsp.names, dsp.target))
+
+	svgHeader(w, plot)
+	io.WriteString(w, b.String())
+	io.WriteString(w, "</svg>\n")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+const defaultThroughSamples = 200 // source/target pairs sampled for the through fraction
+
+// parseThrough gets the junction vertex and the number of sampled pairs from the HTML form
+func (dsp *DijksraSP) parseThrough(r *http.Request) (int, int, error) {
+	str := r.PostFormValue("through")
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return 0, 0, err
+	}
+	if v < 0 || v >= len(dsp.location) {
+		return 0, 0, fmt.Errorf("through vertex %d must be 0-%d", v, len(dsp.location)-1)
+	}
+	dsp.plot.Through = str
+
+	samples := defaultThroughSamples
+	if str := r.PostFormValue("throughsamples"); len(str) > 0 {
+		if samples, err = strconv.Atoi(str); err != nil {
+			fmt.Printf("String %s conversion to int error: %v\n", str, err)
+			return 0, 0, err
+		}
+		if samples < 1 {
+			return 0, 0, fmt.Errorf("through samples %d must be positive", samples)
+		}
+	}
+	dsp.plot.ThroughSamples = strconv.Itoa(samples)
+
+	return v, samples, nil
+}
+
+// findThrough finds the fraction of the SPs between the source/target pairs that
+// route through the junction vertex.  The pairs exclude the junction itself and
+// pairs without a path.  If samples covers every pair all of them are counted,
+// otherwise samples random pairs are drawn from rng.  The SPs of
+// each source are found by one search.  CSS colors the junction vertex.
+func (dsp *DijksraSP) findThrough(junction, samples int, rng *rand.Rand) error {
+	candidates := make([]int, 0, len(dsp.location))
+	for v := range dsp.location {
+		if v != junction && dsp.inClip(v) {
+			candidates = append(candidates, v)
+		}
+	}
+	all := len(candidates) * (len(candidates) - 1) / 2
+	if all == 0 {
+		return fmt.Errorf("through vertex %d needs two other vertices", junction)
+	}
+
+	// Targets of each source
+	targets := make(map[int][]int)
+	if samples >= all {
+		samples = all
+		for i, s := range candidates {
+			targets[s] = append(targets[s], candidates[i+1:]...)
+		}
+	} else {
+		for i := 0; i < samples; {
+			s := candidates[rng.Intn(len(candidates))]
+			t := candidates[rng.Intn(len(candidates))]
+			if s == t {
+				continue
+			}
+			targets[s] = append(targets[s], t)
+			i++
+		}
+	}
+
+	paths, through := 0, 0
+	for _, s := range candidates {
+		if len(targets[s]) == 0 {
+			continue
+		}
+		distTo, prev := dsp.shortestFrom(s)
+		for _, t := range targets[s] {
+			if distTo[t] == infinity {
+				continue
+			}
+			paths++
+			for v := prev[t]; v != s; v = prev[v] {
+				if v == junction {
+					through++
+					break
+				}
+			}
+		}
+	}
+
+	dsp.plot.setMarker(dsp.location[junction], "vertexThrough")
+	if paths == 0 {
+		dsp.plot.ThroughFraction = fmt.Sprintf("none of the %d pairs has a path", samples)
+		return nil
+	}
+	kind := "sampled"
+	if samples == all {
+		kind = "all"
+	}
+	dsp.plot.ThroughFraction = fmt.Sprintf("%.1f%% (%d of %d %s SPs)", 100*float64(through)/float64(paths), through, paths, kind)
+
+	return nil
+}
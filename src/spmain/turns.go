@@ -0,0 +1,158 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"strconv"
+)
+
+const defaultTurnAngle = 30.0 // heading change in degrees that counts as a turn
+
+// turnState is a vertex reached by the edge from prev with turns used so far.  The
+// incoming edge gives the exact heading, so no direction bucketing is needed.
+type turnState struct {
+	prev  int // vertex before v on the path, -1 at the source
+	v     int // vertex reached
+	turns int // turns used from the source to v
+}
+
+// turnItem is a turnState in the priority queue
+type turnItem struct {
+	turnState
+	distance float64
+}
+
+// turnQueue is a min-heap of turnItems ordered by distance
+type turnQueue []*turnItem
+
+func (tq turnQueue) Len() int            { return len(tq) }
+func (tq turnQueue) Swap(i, j int)       { tq[i], tq[j] = tq[j], tq[i] }
+func (tq *turnQueue) Push(x interface{}) { *tq = append(*tq, x.(*turnItem)) }
+
+// Less orders the items by distance, ties within epsilon go to the lower vertex
+func (tq turnQueue) Less(i, j int) bool {
+	if lessDistance(tq[i].distance, tq[j].distance) {
+		return true
+	}
+	if lessDistance(tq[j].distance, tq[i].distance) {
+		return false
+	}
+	return tq[i].v < tq[j].v
+}
+func (tq *turnQueue) Pop() interface{} {
+	old := *tq
+	item := old[len(old)-1]
+	*tq = old[:len(old)-1]
+	return item
+}
+
+// isTurn returns true if the heading changes by more than angle degrees going
+// from u to v and then from v to w
+func (dsp *DijksraSP) isTurn(u, v, w int, angle float64) bool {
+	in := dsp.location[v] - dsp.location[u]
+	out := dsp.location[w] - dsp.location[v]
+	if in == 0 || out == 0 {
+		return false
+	}
+	change := math.Abs(cmplx.Phase(out/in)) * 180 / math.Pi
+	return change > angle
+}
+
+// findSPMaxTurns finds the shortest path from source to target with at most maxTurns
+// turns by running Dijkstra on the states (vertex, incoming edge, turns used).  It
+// returns the path vertices from source to target, its distance and turn count.
+func (dsp *DijksraSP) findSPMaxTurns(maxTurns int, angle float64) ([]int, float64, int, error) {
+	distTo := make(map[turnState]float64)
+	from := make(map[turnState]turnState)
+
+	start := turnState{prev: -1, v: dsp.source}
+	distTo[start] = 0.0
+	tq := &turnQueue{{turnState: start}}
+	for tq.Len() > 0 {
+		item := heap.Pop(tq).(*turnItem)
+		s := item.turnState
+		if item.distance > distTo[s] {
+			continue
+		}
+		if s.v == dsp.target {
+			// Walk back through the states to get the path
+			path := []int{s.v}
+			for cur := s; cur.prev >= 0; {
+				cur = from[cur]
+				path = append(path, cur.v)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, item.distance, s.turns, nil
+		}
+		for _, e := range dsp.adj[s.v] {
+			w := e.w
+			if w == s.v {
+				w = e.v
+			}
+			if w == s.prev {
+				continue
+			}
+			next := turnState{prev: s.v, v: w, turns: s.turns}
+			if s.prev >= 0 && dsp.isTurn(s.prev, s.v, w, angle) {
+				next.turns++
+			}
+			if next.turns > maxTurns {
+				continue
+			}
+			newDistance := item.distance + dsp.graph[s.v][w]
+			if d, ok := distTo[next]; !ok || lessDistance(newDistance, d) {
+				distTo[next] = newDistance
+				from[next] = s
+				heap.Push(tq, &turnItem{turnState: next, distance: newDistance})
+			}
+		}
+	}
+
+	return nil, 0, 0, fmt.Errorf("no path from %d to %d with at most %d turns", dsp.source, dsp.target, maxTurns)
+}
+
+// plotMaxTurns finds and draws the shortest path with at most the maxturns form
+// value turns, where a turn is a heading change greater than the turnangle value
+func (dsp *DijksraSP) plotMaxTurns(r *http.Request) error {
+	str := r.PostFormValue("maxturns")
+	dsp.plot.MaxTurns = str
+	maxTurns, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return err
+	}
+	if maxTurns < 0 {
+		return fmt.Errorf("max turns %d must be 0 or more", maxTurns)
+	}
+	angle := defaultTurnAngle
+	if str := r.PostFormValue("turnangle"); len(str) > 0 {
+		angle, err = strconv.ParseFloat(str, 64)
+		if err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", str, err)
+			return err
+		}
+		if angle < 0 || angle >= 180 {
+			return fmt.Errorf("turn angle %g must be 0-180 degrees", angle)
+		}
+	}
+	dsp.plot.TurnAngle = strconv.FormatFloat(angle, 'g', -1, 64)
+
+	path, distance, turns, err := dsp.findSPMaxTurns(maxTurns, angle)
+	if err != nil {
+		return err
+	}
+
+	dsp.plotPath(path, "edgeTurn")
+	dsp.plot.setMarker(dsp.location[dsp.source], "vertexSP1")
+	dsp.plot.setMarker(dsp.location[dsp.target], "vertexSP2")
+
+	dsp.plot.Turns = strconv.Itoa(turns)
+	dsp.plot.TurnDistance = dsp.plot.withUnits(fmt.Sprintf("%.2f", distance))
+
+	return nil
+}
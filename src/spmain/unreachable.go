@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// plotUnreachable settles every vertex from the source and marks the vertices it
+// cannot reach.  The MST connects all the vertices, so only the clip rectangle
+// leaves vertices unreachable.  CSS colors the unreachable vertex.
+func (dsp *DijksraSP) plotUnreachable() {
+	distTo, _ := dsp.shortestFrom(dsp.source)
+	unreachable := make([]string, 0)
+	for v, d := range distTo {
+		if d != infinity {
+			continue
+		}
+		unreachable = append(unreachable, strconv.Itoa(v))
+		dsp.plot.setCell(real(dsp.location[v]), imag(dsp.location[v]), "vertexUnreachable")
+	}
+	dsp.plot.UnreachableCount = strconv.Itoa(len(unreachable))
+	dsp.plot.UnreachableList = strings.Join(unreachable, ",")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Type to contain the MST verification JSON response
+type VerifyMSTT struct {
+	Status  string    `json:"status"`            // ok, or the violation message
+	Edge    *MSTEdgeT `json:"edge,omitempty"`    // MST edge violating the cut property
+	Cheaper *MSTEdgeT `json:"cheaper,omitempty"` // lighter edge crossing the cut of the MST edge
+}
+
+// verifyMST checks that every MST edge is the minimum weight edge crossing the cut
+// made by removing it from the tree (the cut property).  It returns the violating
+// MST edge and a lighter crossing edge, or nil edges if the MST is minimal.
+func (p *PrimMST) verifyMST() (*Edge, *Edge, error) {
+	vertices := len(p.location)
+	tree := make([][]int, vertices)
+	edges := 0
+	for _, e := range p.mst.edges() {
+		if e == nil {
+			continue
+		}
+		tree[e.v] = append(tree[e.v], e.w)
+		tree[e.w] = append(tree[e.w], e.v)
+		edges++
+	}
+	if edges != vertices-1 {
+		return nil, nil, fmt.Errorf("MST has %d edges, a spanning tree of %d vertices has %d", edges, vertices, vertices-1)
+	}
+
+	side := make([]bool, vertices)
+	for _, e := range p.mst.edges() {
+		// Mark the vertices on the w side of the tree without the edge v-w
+		for i := range side {
+			side[i] = false
+		}
+		side[e.w] = true
+		stack := []int{e.w}
+		for len(stack) > 0 {
+			u := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, x := range tree[u] {
+				if side[x] || (u == e.w && x == e.v) {
+					continue
+				}
+				side[x] = true
+				stack = append(stack, x)
+			}
+		}
+		if side[e.v] {
+			return nil, nil, fmt.Errorf("MST has a cycle through edge %d-%d", e.v, e.w)
+		}
+
+		// Every graph edge crossing the cut must weigh at least as much as v-w
+		weight := p.graph[e.v][e.w]
+		for u := 0; u < vertices; u++ {
+			if !side[u] {
+				continue
+			}
+			for x := 0; x < vertices; x++ {
+				if side[x] || p.graph[u][x] >= weight {
+					continue
+				}
+				return e, &Edge{v: x, w: u}, nil
+			}
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// HTTP handler for /api/verifymst connections.  It computes the MST of the saved
+// graph and verifies the cut property of every MST edge.
+func handleVerifyMST(w http.ResponseWriter, r *http.Request) {
+	primmst, code, err := apiMST(r)
+	if err != nil {
+		writeAPIError(w, code, err.Error())
+		return
+	}
+
+	bad, cheaper, err := primmst.verifyMST()
+	if err != nil {
+		writeJSON(w, VerifyMSTT{Status: err.Error()})
+		return
+	}
+	if bad == nil {
+		writeJSON(w, VerifyMSTT{Status: "ok"})
+		return
+	}
+	writeJSON(w, VerifyMSTT{
+		Status:  fmt.Sprintf("MST edge %d-%d violates the cut property", bad.v, bad.w),
+		Edge:    &MSTEdgeT{V: bad.v, W: bad.w, Distance: primmst.graph[bad.v][bad.w]},
+		Cheaper: &MSTEdgeT{V: cheaper.v, W: cheaper.w, Distance: primmst.graph[cheaper.v][cheaper.w]},
+	})
+}
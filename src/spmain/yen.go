@@ -0,0 +1,200 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// kPathClasses are the CSS classes of the K shortest paths, the first is the SP
+var kPathClasses = []string{"edgeSP", "edgeSP2", "edgeSP3", "edgeSP4", "edgeSP5", "edgeSP6"}
+
+// Type to contain one of the K shortest paths of the paths table
+type KPathT struct {
+	Class    string // CSS class of the drawn path
+	Distance string // distance of the path
+	Hops     string // edges of the path
+	Route    string // path vertices from source to target by name
+}
+
+// parseKPaths gets the number of shortest paths from the HTML form
+func (dsp *DijksraSP) parseKPaths(r *http.Request) (int, error) {
+	str := r.PostFormValue("k")
+	k, err := strconv.Atoi(str)
+	if err != nil {
+		fmt.Printf("String %s conversion to int error: %v\n", str, err)
+		return 0, err
+	}
+	if k < 1 || k > len(kPathClasses) {
+		return 0, fmt.Errorf("K shortest paths %d must be 1-%d", k, len(kPathClasses))
+	}
+	dsp.plot.KPaths = str
+	return k, nil
+}
+
+// spurPath finds the shortest path from spur to the target that avoids the removed
+// vertices and the removed edges, keyed by their vertices in both orders.  It returns
+// nil if there is none.
+func (dsp *DijksraSP) spurPath(spur int, removedVertex []bool, removedEdge map[[2]int]bool) []int {
+	vertices := len(dsp.adj)
+	distTo := make([]float64, vertices)
+	prev := make([]int, vertices)
+	for i := range distTo {
+		distTo[i] = infinity
+		prev[i] = -1
+	}
+
+	// Items are inserted again instead of updated, stale items are skipped
+	pq := newPriorityQueue()
+	distTo[spur] = 0.0
+	heap.Push(&pq, &Item{Edge: Edge{v: spur, w: spur}, distance: 0.0})
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(*Item)
+		v := item.w
+		if item.distance > distTo[v] {
+			continue
+		}
+		if v == dsp.target {
+			break
+		}
+		for _, e := range dsp.adj[v] {
+			w := e.w
+			if w == v {
+				w = e.v
+			}
+			if removedVertex[w] || removedEdge[[2]int{v, w}] {
+				continue
+			}
+			if newDistance := distTo[v] + dsp.graph[v][w]; lessDistance(newDistance, distTo[w]) {
+				distTo[w] = newDistance
+				prev[w] = v
+				heap.Push(&pq, &Item{Edge: Edge{v: v, w: w}, distance: newDistance})
+			}
+		}
+	}
+	if distTo[dsp.target] == infinity {
+		return nil
+	}
+
+	path := make([]int, 0)
+	for v := dsp.target; v != -1; v = prev[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// pathDistance sums the edge weights of the path
+func (dsp *DijksraSP) pathDistance(path []int) float64 {
+	distance := 0.0
+	for i := 1; i < len(path); i++ {
+		distance += dsp.graph[path[i-1]][path[i]]
+	}
+	return distance
+}
+
+// findKPaths finds up to k shortest loopless paths from source to target with Yen's
+// algorithm, starting from the SP found by findSP.  Each path after the first leaves
+// a previous path at a spur vertex, avoiding the edges the earlier paths with the
+// same root take from it and the root vertices.  Fewer than k paths are drawn if
+// the graph has no more, the MST has exactly one path between two vertices.
+func (dsp *DijksraSP) findKPaths(k int) error {
+	first := dsp.pathVertices()
+	if first == nil {
+		return fmt.Errorf("distance to vertex %d not found", dsp.target)
+	}
+	paths := [][]int{first}
+	candidates := make([][]int, 0)
+	samePath := func(a, b []int) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	known := func(path []int) bool {
+		for _, p := range paths {
+			if samePath(p, path) {
+				return true
+			}
+		}
+		for _, p := range candidates {
+			if samePath(p, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for len(paths) < k {
+		last := paths[len(paths)-1]
+		for i := 0; i < len(last)-1; i++ {
+			spur, root := last[i], last[:i+1]
+			removedEdge := make(map[[2]int]bool)
+			for _, p := range paths {
+				if len(p) > i+1 && samePath(p[:i+1], root) {
+					removedEdge[[2]int{p[i], p[i+1]}] = true
+					removedEdge[[2]int{p[i+1], p[i]}] = true
+				}
+			}
+			removedVertex := make([]bool, len(dsp.location))
+			for _, v := range root[:i] {
+				removedVertex[v] = true
+			}
+			spurPath := dsp.spurPath(spur, removedVertex, removedEdge)
+			if spurPath == nil {
+				continue
+			}
+			path := append(append([]int{}, root[:i]...), spurPath...)
+			if !known(path) {
+				candidates = append(candidates, path)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		// The shortest candidate is the next path
+		best := 0
+		for i := range candidates {
+			if lessDistance(dsp.pathDistance(candidates[i]), dsp.pathDistance(candidates[best])) {
+				best = i
+			}
+		}
+		paths = append(paths, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	// Draw the longer paths first so the shorter ones stay on top, the source and
+	// target markers last
+	for i := len(paths) - 1; i >= 0; i-- {
+		dsp.plotPath(paths[i], kPathClasses[i])
+	}
+	dsp.plot.setMarker(dsp.location[dsp.source], "vertexSP1")
+	dsp.plot.setMarker(dsp.location[dsp.target], "vertexSP2")
+
+	dsp.plot.KShortest = make([]KPathT, len(paths))
+	for i, path := range paths {
+		route := make([]string, len(path))
+		for j, v := range path {
+			route[j] = vertexName(dsp.names, v)
+		}
+		dsp.plot.KShortest[i] = KPathT{
+			Class:    kPathClasses[i],
+			Distance: dsp.plot.withUnits(fmt.Sprintf("%.2f", dsp.pathDistance(path))),
+			Hops:     strconv.Itoa(len(path) - 1),
+			Route:    strings.Join(route, " -> "),
+		}
+	}
+	if len(paths) < k {
+		dsp.plot.KShortestNote = fmt.Sprintf("only %d of %d loopless paths exist", len(paths), k)
+	}
+	return nil
+}
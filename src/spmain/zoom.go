@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// parseZoom gets the optional zoom box from the HTML form, a region inside the
+// Euclidean graph endpoints that is shown in the whole grid.  It returns nil if the
+// form has no zoom box.
+func (p *PrimMST) parseZoom(r *http.Request) (*Endpoints, error) {
+	names := []string{"zoomxmin", "zoomymin", "zoomxmax", "zoomymax"}
+	values := make([]float64, len(names))
+	set := 0
+	for i, name := range names {
+		str := r.PostFormValue(name)
+		if len(str) == 0 {
+			continue
+		}
+		var err error
+		if values[i], err = strconv.ParseFloat(str, 64); err != nil {
+			fmt.Printf("String %s conversion to float error: %v\n", str, err)
+			return nil, err
+		}
+		set++
+	}
+	if set == 0 {
+		return nil, nil
+	}
+	if set < len(names) {
+		return nil, fmt.Errorf("zoom box needs x start, y start, x end and y end")
+	}
+
+	xmin, ymin, xmax, ymax := values[0], values[1], values[2], values[3]
+	// Check if xmin < xmax and ymin < ymax and correct if necessary
+	if xmin > xmax {
+		xmin, xmax = xmax, xmin
+	}
+	if ymin > ymax {
+		ymin, ymax = ymax, ymin
+	}
+	zoom := &Endpoints{xmin: xmin, ymin: ymin, xmax: xmax, ymax: ymax}
+	if zoom.degenerate() {
+		return nil, fmt.Errorf("zoom box (%g, %g) - (%g, %g) is too small", xmin, ymin, xmax, ymax)
+	}
+	if !p.contains(xmin, ymin) || !p.contains(xmax, ymax) {
+		return nil, fmt.Errorf("zoom box (%g, %g) - (%g, %g) is outside the bounds (%g, %g) - (%g, %g)",
+			xmin, ymin, xmax, ymax, p.xmin, p.ymin, p.xmax, p.ymax)
+	}
+	p.plot.ZoomXmin = fmt.Sprintf("%.2f", xmin)
+	p.plot.ZoomYmin = fmt.Sprintf("%.2f", ymin)
+	p.plot.ZoomXmax = fmt.Sprintf("%.2f", xmax)
+	p.plot.ZoomYmax = fmt.Sprintf("%.2f", ymax)
+
+	return zoom, nil
+}
+
+// clipSegment clips the line segment between the start and end locations to the
+// endpoints with the Liang-Barsky algorithm.  It returns false if the segment is
+// outside.  An end moved onto the boundary is clamped into the endpoints, so the
+// rounding does not put it just outside.
+func (ep *Endpoints) clipSegment(start, end complex128) (complex128, complex128, bool) {
+	dx, dy := real(end)-real(start), imag(end)-imag(start)
+	// p is the direction and q the distance to each boundary, left, right, bottom, top
+	p := [4]float64{-dx, dx, -dy, dy}
+	q := [4]float64{real(start) - ep.xmin, ep.xmax - real(start), imag(start) - ep.ymin, ep.ymax - imag(start)}
+	t0, t1 := 0.0, 1.0
+	for i := range p {
+		if p[i] == 0 {
+			// parallel to the boundary
+			if q[i] < 0 {
+				return start, end, false
+			}
+			continue
+		}
+		t := q[i] / p[i]
+		if p[i] < 0 {
+			if t > t1 {
+				return start, end, false
+			}
+			t0 = math.Max(t0, t)
+		} else {
+			if t < t0 {
+				return start, end, false
+			}
+			t1 = math.Min(t1, t)
+		}
+	}
+
+	clamp := func(x, y float64) complex128 {
+		return complex(math.Max(ep.xmin, math.Min(x, ep.xmax)), math.Max(ep.ymin, math.Min(y, ep.ymax)))
+	}
+	if t1 < 1 {
+		end = clamp(real(start)+t1*dx, imag(start)+t1*dy)
+	}
+	if t0 > 0 {
+		start = clamp(real(start)+t0*dx, imag(start)+t0*dy)
+	}
+	return start, end, true
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestZoom plots a graph in a zoom box and checks the axis labels, that the edge
+// leaving the box is drawn up to its corner and that nothing outside the box is drawn.
+// A segment passing through the box with both ends outside is drawn across it.
+func TestZoom(t *testing.T) {
+	bounds := Endpoints{xmin: 0, ymin: 0, xmax: 10, ymax: 10}
+	location := []complex128{complex(1, 1), complex(2, 2), complex(9, 9)}
+	plot := &PlotT{Units: defaultUnits, supersample: 1}
+	primmst := &PrimMST{plot: plot, location: location, metric: metricEuclidean, Endpoints: &bounds}
+	zoom, err := primmst.parseZoom(postForm(url.Values{"zoomxmin": {"0"}, "zoomymin": {"0"}, "zoomxmax": {"4"}, "zoomymax": {"4"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plot.view = zoom
+	if err := primmst.findDistances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.findMST(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotGrid(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primmst.plotMST(nil); err != nil {
+		t.Fatal(err)
+	}
+	if plot.Xlabel[0] != "0.00" || plot.Xlabel[xlabels-1] != "4.00" || plot.Ylabel[ylabels-1] != "4.00" {
+		t.Fatalf("zoomed labels x %s-%s y %s-%s, want 0.00-4.00", plot.Xlabel[0], plot.Xlabel[xlabels-1], plot.Ylabel[0], plot.Ylabel[ylabels-1])
+	}
+	// The edge from (2, 2) to (9, 9) leaves the box at its top right corner
+	if class := plot.Grid[defaultGridSize-1]; class != "edge" {
+		t.Fatalf("zoom box corner cell is %q, want edge", class)
+	}
+
+	plot.Grid = make([]string, defaultGridSize*defaultGridSize)
+	plot.drawSegment(complex(5, 5), complex(9, 1), "edge")
+	for _, class := range plot.Grid {
+		if len(class) > 0 {
+			t.Fatalf("segment outside the zoom box is drawn")
+		}
+	}
+	plot.drawSegment(complex(-1, 2), complex(10, 2), "edge")
+	row, _ := plot.toCell(0, 2)
+	for _, col := range []int{0, defaultGridSize - 1} {
+		if len(plot.Grid[row*defaultGridSize+col]) == 0 {
+			t.Fatalf("segment across the zoom box is not drawn at column %d", col)
+		}
+	}
+
+	for _, form := range []url.Values{{"zoomxmin": {"1"}}, {"zoomxmin": {"-1"}, "zoomymin": {"0"}, "zoomxmax": {"4"}, "zoomymax": {"4"}},
+		{"zoomxmin": {"2"}, "zoomymin": {"0"}, "zoomxmax": {"2"}, "zoomymax": {"4"}}} {
+		if _, err := primmst.parseZoom(postForm(form)); err == nil {
+			t.Fatalf("zoom box %s is not rejected", form.Encode())
+		}
+	}
+}